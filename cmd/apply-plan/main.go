@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/git"
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/repo/backend"
+	"github.com/bretmckee/git-tools/pkg/urls"
+	"github.com/golang/glog"
+)
+
+func main() {
+	var (
+		dryRun     = flag.Bool("dry-run", false, "Dry Run mode -- only check that the plan can be applied")
+		baseURL    = flag.String("url", "", "GitHub Base URL")
+		configPath = flag.String("config", config.DefaultPath(), "Path of the configuration file selecting the backend of the repo")
+		journalDir = flag.String("journal", journal.Dir(), "Directory of the journals recording changes for undo-run (empty to disable)")
+		login      = flag.String("login", "", "Login of the user to apply the plan for.")
+		planPath   = flag.String("plan", "", "File containing the plan to apply (- for standard input)")
+		repoDir    = flag.String("repo-dir", ".", "Local clone used for the git operations of the plan")
+		show       = flag.Bool("show", false, "Print the operations of the plan instead of applying it")
+		token      = flag.String("token", "", "github auth token to use (also checks environment GITHUB_TOKEN")
+		uploadURL  = flag.String("upload", "", "GitHub Upload URL")
+	)
+	flag.Parse()
+	if *planPath == "" {
+		glog.Exit("A non-empty value must be specified for the flag `-plan`")
+	}
+
+	p, err := plan.Load(*planPath)
+	if err != nil {
+		glog.Exitf("failed to load plan: %v", err)
+	}
+	if *show {
+		if err := p.Print(os.Stdout); err != nil {
+			glog.Exitf("failed to print plan: %v", err)
+		}
+		return
+	}
+
+	if *token == "" {
+		*token = os.Getenv("GITHUB_TOKEN")
+	}
+	if *token == "" {
+		glog.Exit("Unauthorized: No token present")
+	}
+	if *login == "" {
+		glog.Exitf("A non-empty value must be specified for the flag `-login (=%q)`", *login)
+	}
+
+	b, u, err := urls.Get(*baseURL, *uploadURL)
+	if err != nil {
+		glog.Exitf("failed to get URLs: %v", err)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		glog.Exitf("failed to load config: %v", err)
+	}
+
+	c, err := backend.Create(cfg, b, u, p.Owner, p.Repo, *login, *token)
+	if err != nil {
+		glog.Exitf("failed to create client: %v", err)
+	}
+	var r repo.Repo = c
+	var j *journal.Journal
+	if *journalDir != "" {
+		j = journal.Open(journal.Path(*journalDir, p.Owner, p.Repo), "apply-plan")
+		r = journal.Wrap(c, j)
+	}
+
+	err = plan.Apply(r, git.New(*repoDir), j, p, *dryRun)
+	if !*dryRun && *planPath != "-" {
+		// Record how far the plan got, so that applying it again after a
+		// failure carries on from there.
+		if err := p.Save(*planPath); err != nil {
+			glog.Errorf("failed to record progress in plan: %v", err)
+		}
+	}
+	if err != nil {
+		glog.Exitf("failed to apply plan: %v", err)
+	}
+}
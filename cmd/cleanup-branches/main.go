@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/git"
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/repo/backend"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/bretmckee/git-tools/pkg/urls"
+	"github.com/golang/glog"
+)
+
+// cleanupBranches deletes the remote, and if `g` is not nil local, branches
+// starting with `prefix` whose pull requests have been merged. A branch is only
+// deleted if it still points at the head the pull request was merged with, so
+// work pushed or committed to it since is never lost. If `p` is not nil the
+// deletions of local branches are added to it instead of being made.
+func cleanupBranches(c repo.Repo, g *git.Git, p *plan.Plan, prefix string) error {
+	var local []string
+	if g != nil {
+		var err error
+		if local, err = g.LocalBranches(); err != nil {
+			return fmt.Errorf("unable to get local branches: %v", err)
+		}
+	}
+	localSet := make(map[string]bool)
+	for _, name := range local {
+		localSet[name] = true
+	}
+
+	merged, err := stack.MergedBranches(c, prefix, local)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, b := range merged {
+		if b.Remote {
+			rb, err := c.Branch(b.Name)
+			if err != nil {
+				glog.Warningf("unable to read remote branch %s: %v", b.Name, err)
+				failed++
+			} else if sha := rb.GetCommit().GetSHA(); sha != b.SHA {
+				glog.Warningf("not deleting remote branch %s: it is at %s but PR %d was merged at %s", b.Name, sha, b.Number, b.SHA)
+			} else if err := c.DeleteBranch(b.Name); err != nil {
+				glog.Warningf("failed to delete remote branch %s: %v", b.Name, err)
+				failed++
+			} else {
+				glog.Infof("deleted remote branch %s of merged PR %d", b.Name, b.Number)
+			}
+		}
+		if !localSet[b.Name] {
+			continue
+		}
+		sha, err := g.RevParse("refs/heads/" + b.Name)
+		if err != nil {
+			glog.Warningf("unable to read local branch %s: %v", b.Name, err)
+			failed++
+			continue
+		}
+		if sha != b.SHA {
+			glog.Warningf("not deleting local branch %s: it is at %s but PR %d was merged at %s", b.Name, sha, b.Number, b.SHA)
+			continue
+		}
+		if p != nil {
+			p.Add(plan.Op{Kind: plan.OpDeleteLocalBranch, Number: b.Number, Ref: b.Name, SHA: sha})
+		} else if err := g.DeleteBranch(b.Name); err != nil {
+			glog.Warningf("failed to delete local branch %s: %v", b.Name, err)
+			failed++
+		} else {
+			glog.Infof("deleted local branch %s of merged PR %d", b.Name, b.Number)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d branches could not be deleted", failed)
+	}
+	return nil
+}
+
+func main() {
+	var (
+		dryRun      = flag.Bool("dry-run", false, "Dry Run mode -- print the changes instead of making them")
+		baseURL     = flag.String("url", "", "GitHub Base URL")
+		configPath  = flag.String("config", config.DefaultPath(), "Path of the configuration file selecting the backend of the repo")
+		journalDir  = flag.String("journal", journal.Dir(), "Directory of the journals recording changes for undo-run (empty to disable)")
+		local       = flag.Bool("local", true, "Also delete the local branches of merged PRs in -repo-dir")
+		login       = flag.String("login", "", "Login of the user to clean up for.")
+		planPath    = flag.String("plan", "", "Save the changes as a plan for apply-plan in this file (- for standard output) instead of making them")
+		prefix      = flag.String("prefix", "", "Only branches whose names start with this prefix (e.g. `user/`) are deleted")
+		repoDir     = flag.String("repo-dir", ".", "Directory of the local clone, used with -local")
+		sourceOwner = flag.String("source-owner", "", "Name of the owner (user or org) of the repo to create the commit in.")
+		sourceRepo  = flag.String("source-repo", "", "Name of repo to create the commit in.")
+		token       = flag.String("token", "", "github auth token to use (also checks environment GITHUB_TOKEN")
+		uploadURL   = flag.String("upload", "", "GitHub Upload URL")
+	)
+	flag.Parse()
+	if *token == "" {
+		*token = os.Getenv("GITHUB_TOKEN")
+	}
+	if *token == "" {
+		glog.Exit("Unauthorized: No token present")
+	}
+	if *sourceOwner == "" || *sourceRepo == "" || *login == "" {
+		glog.Exitf("A non-empty value must be specified for the flags `-source-owner (=%q)`, `-source-repo (=%q)` and `-login (=%q)`", *sourceOwner, *sourceRepo, *login)
+	}
+	if *prefix == "" {
+		glog.Exit("A non-empty value must be specified for `-prefix`")
+	}
+
+	b, u, err := urls.Get(*baseURL, *uploadURL)
+	if err != nil {
+		glog.Exitf("failed to get URLs: %v", err)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		glog.Exitf("failed to load config: %v", err)
+	}
+
+	c, err := backend.Create(cfg, b, u, *sourceOwner, *sourceRepo, *login, *token)
+	if err != nil {
+		glog.Exitf("failed to create client: %v", err)
+	}
+
+	var g *git.Git
+	if *local {
+		g = git.New(*repoDir)
+	}
+	var r repo.Repo = c
+	var p *plan.Plan
+	if *dryRun || *planPath != "" {
+		rec := plan.NewRecorder(c, *sourceOwner, *sourceRepo, "cleanup-branches")
+		r, p = rec, rec.Plan
+	} else if *journalDir != "" {
+		r = journal.Wrap(c, journal.Open(journal.Path(*journalDir, *sourceOwner, *sourceRepo), "cleanup-branches"))
+	}
+	if err := cleanupBranches(r, g, p, *prefix); err != nil {
+		glog.Exitf("cleanupBranches failed: %v", err)
+	}
+	if p != nil {
+		if err := p.Report(*planPath); err != nil {
+			glog.Exitf("failed to report plan: %v", err)
+		}
+	}
+}
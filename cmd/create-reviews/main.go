@@ -4,46 +4,43 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"regexp"
 
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/plan"
 	"github.com/bretmckee/git-tools/pkg/repo/repodata"
+	"github.com/bretmckee/git-tools/pkg/stack"
 	"github.com/bretmckee/git-tools/pkg/urls"
 	"github.com/golang/glog"
 	"github.com/google/go-github/v28/github"
 	"github.com/kr/pretty"
 )
 
-func createPR(r *repodata.RepoData, branch, base, oldest, newest string, draft, dryRun bool) error {
-	o, err := r.Commit(oldest)
+func createPR(r *repodata.RepoData, branch, base, oldest, newest string, draft bool) (*github.PullRequest, error) {
+	title, body, meta, err := prText(r, oldest)
 	if err != nil {
-		return fmt.Errorf("CreatePR failed to get oldest commit %s: %v", oldest, err)
-	}
-	glog.V(2).Infof("Oldest Commit: %# v\n", pretty.Formatter(*o))
-	values := regexp.MustCompile("[\n]+").Split(*o.Message, 2)
-	if len(values) != 2 {
-		return fmt.Errorf("CreatePR failed to split the message for commit %s (it probably does not have a body)", oldest)
+		return nil, fmt.Errorf("CreatePR failed: %v", err)
 	}
 
 	npr := &github.NewPullRequest{
-		Title:               github.String(values[0]),
-		Head:                github.String(branch),
+		Title:               github.String(title),
+		Head:                github.String(r.HeadRef(branch)),
 		Base:                github.String(base),
-		Body:                github.String(values[1]),
+		Body:                github.String(body),
 		MaintainerCanModify: github.Bool(false),
 		Draft:               github.Bool(draft),
 	}
 	glog.V(2).Infof("npr=%# v", pretty.Formatter(*npr))
-	if dryRun {
-		glog.Infof("dryrun skipping: Creating PR for branch %s based on %s, oldest=%s, newest=%s", branch, base, oldest, newest)
-		return nil
-	}
 	glog.V(2).Infof("Creating PR for branch %s based on %s, oldest=%s, newest=%s", branch, base, oldest, newest)
 	pr, err := r.CreatePullRequest(npr)
 	if err != nil {
-		return fmt.Errorf("createPR failed to pr for %s:%v", branch, err)
+		return nil, fmt.Errorf("createPR failed to pr for %s:%v", branch, err)
 	}
 	glog.Infof("Created PR %d for branch %s", *pr.Number, branch)
-	return nil
+	if err := applyMetadata(r, *pr.Number, meta); err != nil {
+		return nil, fmt.Errorf("createPR failed to apply metadata to %d: %v", *pr.Number, err)
+	}
+	return pr, nil
 }
 
 func findBranch(baseBranch string, branches []*github.Branch) (*github.Branch, error) {
@@ -56,37 +53,41 @@ func findBranch(baseBranch string, branches []*github.Branch) (*github.Branch, e
 				return br, nil
 			}
 		}
-		return nil, fmt.Errorf("findBranch: failed to find non-base branch for %s", branches[0].Commit.SHA)
+		return nil, fmt.Errorf("findBranch: failed to find non-base branch for %s", branches[0].GetCommit().GetSHA())
 	default:
-		return nil, fmt.Errorf("findbranch: commit %s has invalid number of branches (%d), expect 1 or 2", branches[0].Commit.SHA, l)
+		return nil, fmt.Errorf("findbranch: commit %s has invalid number of branches (%d), expect 1 or 2", branches[0].GetCommit().GetSHA(), l)
 	}
 }
 
 // createPRs createa any needed Pull Requests for commits in the range
-// baseBranch...tipBranch.
-func createPRs(r *repodata.RepoData, tipBranch, baseBranch string, maxCreates int, includeBranch, draft, dryRun bool) error {
-	b, err := r.Branch(tipBranch)
+// baseBranch...tipBranch. It returns the numbers of the pull requests in the
+// range, whether or not they were created by this call. If the branches are in a
+// fork every pull request is based on baseBranch, since a branch of the fork
+// cannot be the base of a pull request against the source repository.
+func createPRs(r *repodata.RepoData, tipBranch, baseBranch string, maxCreates int, includeBranch, draft, sync bool) ([]int, error) {
+	b, err := r.Heads.Branch(tipBranch)
 	if err != nil {
-		return fmt.Errorf("failed to get tip branch %q: %v", tipBranch, err)
+		return nil, fmt.Errorf("failed to get tip branch %q: %v", tipBranch, err)
 	}
 	if b.Commit.SHA == nil {
-		return fmt.Errorf("Branch Commit SHA is nil: %# v\n", pretty.Formatter(*b))
+		return nil, fmt.Errorf("Branch Commit SHA is nil: %# v\n", pretty.Formatter(*b))
 	}
 	glog.V(2).Infof("Branch %# v\n", pretty.Formatter(*b))
 
 	bb, err := r.Branch(baseBranch)
 	if err != nil {
-		return fmt.Errorf("failed to get base branch %q: %v", baseBranch, err)
+		return nil, fmt.Errorf("failed to get base branch %q: %v", baseBranch, err)
 	}
 	if bb.Commit.SHA == nil {
-		return fmt.Errorf("Branch Commit SHA is nil: %# v\n", pretty.Formatter(*bb))
+		return nil, fmt.Errorf("Branch Commit SHA is nil: %# v\n", pretty.Formatter(*bb))
 	}
 	glog.V(2).Infof("Base Branch %# v\n", pretty.Formatter(*bb))
 
 	chain, err := r.CommitChain(b.GetCommit().GetSHA(), *bb.Commit.SHA)
 	if err != nil {
-		return fmt.Errorf("Get commit chain failed: %v", err)
+		return nil, fmt.Errorf("Get commit chain failed: %v", err)
 	}
+	var numbers []int
 	created := 0
 	prev := ""
 	base := baseBranch
@@ -94,7 +95,7 @@ func createPRs(r *repodata.RepoData, tipBranch, baseBranch string, maxCreates in
 		glog.V(2).Infof("examining commit %s", commit)
 		if commit == *b.Commit.SHA && !includeBranch {
 			glog.V(2).Infof("skipping tip branch %s", commit)
-			return nil
+			return numbers, nil
 		}
 		if prev == "" {
 			glog.V(2).Infof("setting prev to commit %s", commit)
@@ -108,28 +109,73 @@ func createPRs(r *repodata.RepoData, tipBranch, baseBranch string, maxCreates in
 		}
 		branch, err := findBranch(baseBranch, branches)
 		if err != nil {
-			return fmt.Errorf("failed to find branch: %v", err)
+			return nil, fmt.Errorf("failed to find branch: %v", err)
 		}
 		// We are at a commit that needs a PR. Create one unless there already is
 		// one.
 		if pr, ok := r.PrBySHA[*branch.Commit.SHA]; ok {
 			glog.V(2).Infof("branch %s (sha %s) already has PR %d", *branch.Name, *branch.Commit.SHA, *pr.Number)
-			base = *branch.Name
+			if sync {
+				if err := syncPR(r, pr, prev); err != nil {
+					return nil, fmt.Errorf("failed to sync pr: %v", err)
+				}
+			}
+			numbers = append(numbers, *pr.Number)
+			if !r.Fork() {
+				base = *branch.Name
+			}
 			prev = ""
 			continue
 		}
 		if created >= maxCreates {
-			return fmt.Errorf("maximum number of pull requests (%d) created, skipping creation for branch %s", maxCreates, *branch.Name)
+			return nil, fmt.Errorf("maximum number of pull requests (%d) created, skipping creation for branch %s", maxCreates, *branch.Name)
 		}
-		if err := createPR(r, *branch.Name, base, prev, commit, draft, dryRun); err != nil {
-			return fmt.Errorf("failed to create pr: %v", err)
+		pr, err := createPR(r, *branch.Name, base, prev, commit, draft)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pr: %v", err)
 		}
+		numbers = append(numbers, *pr.Number)
 		created += 1
-		base = *branch.Name
+		if !r.Fork() {
+			base = *branch.Name
+		}
 		prev = ""
 	}
 
-	return nil
+	return numbers, nil
+}
+
+// updateNavigation reloads the pull requests and updates the stack navigation
+// section of every pull request in the stack containing `numbers`. If the
+// branches are in a fork the pull requests are not linked by their bases, so
+// the stack is `numbers` itself.
+func updateNavigation(r *repodata.RepoData, numbers []int) error {
+	if len(numbers) == 0 {
+		return nil
+	}
+	if err := r.LoadData(); err != nil {
+		return fmt.Errorf("failed to reload data: %v", err)
+	}
+	if r.Fork() {
+		var s []*github.PullRequest
+		for _, n := range numbers {
+			pr, ok := r.PrByNumber[n]
+			if !ok {
+				return fmt.Errorf("PR %d not found", n)
+			}
+			s = append(s, pr)
+		}
+		return stack.UpdateNavigation(r, s)
+	}
+	var prs []*github.PullRequest
+	for _, pr := range r.PrByNumber {
+		prs = append(prs, pr)
+	}
+	s, err := stack.Find(prs, numbers[len(numbers)-1])
+	if err != nil {
+		return fmt.Errorf("failed to find stack: %v", err)
+	}
+	return stack.UpdateNavigation(r, s)
 }
 
 func main() {
@@ -137,13 +183,20 @@ func main() {
 		baseBranch    = flag.String("base", "master", "Base Branch")
 		baseURL       = flag.String("url", "", "GitHub Base URL")
 		branch        = flag.String("branch", "", "Starting Branch")
+		configPath    = flag.String("config", config.DefaultPath(), "Path of the configuration file selecting the backend of the repo")
 		draft         = flag.Bool("draft", true, "create draft PR")
-		dryRun        = flag.Bool("dry-run", false, "Dry Run mode -- no pull requests will be created")
+		dryRun        = flag.Bool("dry-run", false, "Dry Run mode -- print the changes instead of making them")
+		headOwner     = flag.String("head-owner", "", "Owner of the fork the branches are pushed to, if they are not in the source repo. Every PR is then based on -base, so its diff also contains the commits of the PRs below it")
+		headRepo      = flag.String("head-repo", "", "Name of the fork the branches are pushed to (defaults to -source-repo)")
 		includeBranch = flag.Bool("include-branch", false, "Create a PR for --branch")
+		journalDir    = flag.String("journal", journal.Dir(), "Directory of the journals recording changes for undo-run (empty to disable)")
 		login         = flag.String("login", "", "Login of the user to create for.")
 		maxCreates    = flag.Int("max-creates", 10, "Maximum number of pull requests to create")
+		navigation    = flag.Bool("navigation", true, "Maintain a stack navigation section in each pull request body")
+		planPath      = flag.String("plan", "", "Save the changes as a plan for apply-plan in this file (- for standard output) instead of making them")
 		sourceOwner   = flag.String("source-owner", "", "Name of the owner (user or org) of the repo to create the commit in.")
 		sourceRepo    = flag.String("source-repo", "", "Name of repo to create the commit in.")
+		sync          = flag.Bool("sync", true, "Update the title and body of existing PRs whose commit message has changed")
 		token         = flag.String("token", "", "github auth token to use (also checks environment GITHUB_TOKEN")
 		uploadURL     = flag.String("upload", "", "GitHub Upload URL")
 	)
@@ -160,19 +213,47 @@ func main() {
 	if *branch == "" || *baseBranch == "" {
 		glog.Exit("Both branch and base must be specified")
 	}
+	if *headRepo == "" {
+		*headRepo = *sourceRepo
+	}
 
 	b, u, err := urls.Get(*baseURL, *uploadURL)
 	if err != nil {
 		glog.Exitf("failed to get URLs: %v", err)
 	}
 
-	r, err := repodata.Create(b, u, *sourceOwner, *sourceRepo, *login, *token)
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		glog.Exitf("failed to load config: %v", err)
+	}
+
+	r, err := repodata.Create(cfg, b, u, *sourceOwner, *sourceRepo, *headOwner, *headRepo, *login, *token)
 	if err != nil {
 		glog.Exitf("failed to create repodata: %v", err)
 	}
+	var rec *plan.Recorder
+	if *dryRun || *planPath != "" {
+		rec = plan.NewRecorder(r.Repo, *sourceOwner, *sourceRepo, "create-reviews")
+		r.Repo = rec
+	} else if *journalDir != "" {
+		r.Repo = journal.Wrap(r.Repo, journal.Open(journal.Path(*journalDir, *sourceOwner, *sourceRepo), "create-reviews"))
+	}
 
-	if err := createPRs(r, *branch, *baseBranch, *maxCreates, *includeBranch, *draft, *dryRun); err != nil {
+	numbers, err := createPRs(r, *branch, *baseBranch, *maxCreates, *includeBranch, *draft, *sync)
+	if err != nil {
 		glog.Exitf("createPRs failed: %v", err)
 	}
 
+	switch {
+	case rec != nil:
+		// The planned pull requests do not exist yet, so the stack cannot be
+		// found. The next run after the plan is applied updates navigation.
+		if err := rec.Plan.Report(*planPath); err != nil {
+			glog.Exitf("failed to report plan: %v", err)
+		}
+	case *navigation:
+		if err := updateNavigation(r, numbers); err != nil {
+			glog.Exitf("updateNavigation failed: %v", err)
+		}
+	}
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bretmckee/git-tools/pkg/repo/repodata"
+	"github.com/bretmckee/git-tools/pkg/trailers"
+)
+
+// Trailers which describe pull request metadata rather than the change
+// itself. They are applied to the pull request and stripped from its body.
+const (
+	reviewersTrailer = "Reviewers"
+	labelsTrailer    = "Labels"
+	assigneeTrailer  = "Assignee"
+	assigneesTrailer = "Assignees"
+)
+
+var metadataTrailers = []string{reviewersTrailer, labelsTrailer, assigneeTrailer, assigneesTrailer}
+
+// metadata is the pull request metadata requested by a commit's trailers.
+type metadata struct {
+	reviewers []string
+	teams     []string
+	labels    []string
+	assignees []string
+}
+
+// splitList splits the comma separated `values` into their non-empty,
+// trimmed elements.
+func splitList(values []string) []string {
+	var res []string
+	for _, v := range values {
+		for _, e := range strings.Split(v, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				res = append(res, e)
+			}
+		}
+	}
+	return res
+}
+
+// parseMetadata extracts the metadata trailers from `t`. Reviewers containing
+// a slash (for example team/backend or org/backend) are teams, and are
+// requested by the slug following the last slash.
+func parseMetadata(t trailers.Trailers) *metadata {
+	m := &metadata{
+		labels:    splitList(t.Get(labelsTrailer)),
+		assignees: splitList(append(t.Get(assigneeTrailer), t.Get(assigneesTrailer)...)),
+	}
+	for _, r := range splitList(t.Get(reviewersTrailer)) {
+		if i := strings.LastIndex(r, "/"); i >= 0 {
+			m.teams = append(m.teams, r[i+1:])
+			continue
+		}
+		m.reviewers = append(m.reviewers, strings.TrimPrefix(r, "@"))
+	}
+	for i, a := range m.assignees {
+		m.assignees[i] = strings.TrimPrefix(a, "@")
+	}
+	return m
+}
+
+// applyMetadata applies `m` to pull request `num`.
+func applyMetadata(r *repodata.RepoData, num int, m *metadata) error {
+	if len(m.reviewers) > 0 || len(m.teams) > 0 {
+		if err := r.RequestReviewers(num, m.reviewers, m.teams); err != nil {
+			return fmt.Errorf("applyMetadata failed: %v", err)
+		}
+	}
+	if len(m.labels) > 0 {
+		if err := r.AddLabels(num, m.labels); err != nil {
+			return fmt.Errorf("applyMetadata failed: %v", err)
+		}
+	}
+	if len(m.assignees) > 0 {
+		if err := r.AddAssignees(num, m.assignees); err != nil {
+			return fmt.Errorf("applyMetadata failed: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bretmckee/git-tools/pkg/repo/repodata"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/bretmckee/git-tools/pkg/trailers"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+	"github.com/kr/pretty"
+)
+
+const (
+	// sourceMarkerPrefix starts the hidden comment which records the commit
+	// message a pull request's title and body were generated from.
+	sourceMarkerPrefix = "<!-- git-tools:source:"
+	markerSuffix       = " -->"
+
+	// noSyncMarker may be added to a pull request body on GitHub to keep
+	// create-reviews from overwriting its title and body.
+	noSyncMarker = "<!-- git-tools:no-sync -->"
+)
+
+// subjectRE separates the subject of a commit message from its body.
+var subjectRE = regexp.MustCompile("[\n]+")
+
+var sourceMarkerRE = regexp.MustCompile(regexp.QuoteMeta(sourceMarkerPrefix) + `([0-9a-f]+)` + regexp.QuoteMeta(markerSuffix))
+
+// messageHash returns a short digest identifying commit message `msg`.
+func messageHash(msg string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(msg)))[:16]
+}
+
+func sourceMarker(msg string) string {
+	return sourceMarkerPrefix + messageHash(msg) + markerSuffix
+}
+
+// recordedSource returns the commit message hash recorded in `body`, or "" if
+// there is none.
+func recordedSource(body string) string {
+	m := sourceMarkerRE.FindStringSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// prText returns the title, body and metadata for a pull request whose oldest
+// commit is `oldest`. Metadata trailers are removed from the body.
+func prText(r *repodata.RepoData, oldest string) (string, string, *metadata, error) {
+	o, err := r.Heads.Commit(oldest)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to get oldest commit %s: %v", oldest, err)
+	}
+	glog.V(2).Infof("Oldest Commit: %# v\n", pretty.Formatter(*o))
+	if len(subjectRE.Split(o.GetMessage(), 2)) != 2 {
+		return "", "", nil, fmt.Errorf("failed to split the message for commit %s (it probably does not have a body)", oldest)
+	}
+	msg := trailers.Parse(o.GetMessage())
+	values := subjectRE.Split(msg.Body, 2)
+	body := ""
+	if len(values) == 2 {
+		body = strings.TrimRight(values[1], "\n") + "\n\n"
+	}
+	if rest := msg.Trailers.Without(metadataTrailers...); len(rest) > 0 {
+		body += rest.String() + "\n"
+	}
+	body += sourceMarker(o.GetMessage()) + "\n"
+	return values[0], body, parseMetadata(msg.Trailers), nil
+}
+
+// syncPR updates the title, body and metadata of `pr` if the commit message
+// they were generated from has changed. Pull requests whose body contains
+// noSyncMarker, or which do not record their source, are left alone.
+func syncPR(r *repodata.RepoData, pr *github.PullRequest, oldest string) error {
+	num := pr.GetNumber()
+	if strings.Contains(pr.GetBody(), noSyncMarker) {
+		glog.V(2).Infof("PR %d has opted out of syncing", num)
+		return nil
+	}
+	recorded := recordedSource(pr.GetBody())
+	if recorded == "" {
+		glog.V(1).Infof("PR %d does not record its source commit message, not syncing", num)
+		return nil
+	}
+	title, body, meta, err := prText(r, oldest)
+	if err != nil {
+		return fmt.Errorf("syncPR failed for PR %d: %v", num, err)
+	}
+	if recordedSource(body) == recorded {
+		glog.V(2).Infof("PR %d is up to date with commit %s", num, oldest)
+		return nil
+	}
+	if nav := stack.FindNavigation(pr.GetBody()); nav != "" {
+		body = stack.ReplaceNavigation(body, nav)
+	}
+	if err := applyMetadata(r, num, meta); err != nil {
+		return fmt.Errorf("syncPR failed for PR %d: %v", num, err)
+	}
+	glog.Infof("Updating title and body of PR %d from commit %s", num, oldest)
+	updated, err := r.EditPullRequest(num, &github.PullRequest{
+		Title: github.String(title),
+		Body:  github.String(body),
+	})
+	if err != nil {
+		return fmt.Errorf("syncPR failed to update PR %d: %v", num, err)
+	}
+	*pr = *updated
+	return nil
+}
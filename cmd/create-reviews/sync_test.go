@@ -0,0 +1,216 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/repo/repodata"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/google/go-github/v28/github"
+)
+
+// fakeRepo serves the commit messages in `messages`, by SHA, and records the
+// edits, review requests and labels that syncPR makes, by pull request.
+type fakeRepo struct {
+	repo.Repo
+	messages  map[string]string
+	edited    map[int]*github.PullRequest
+	reviewers map[int][]string
+	labels    map[int][]string
+}
+
+func newFakeRepo(messages map[string]string) *repodata.RepoData {
+	f := &fakeRepo{
+		messages:  messages,
+		edited:    make(map[int]*github.PullRequest),
+		reviewers: make(map[int][]string),
+		labels:    make(map[int][]string),
+	}
+	return &repodata.RepoData{Repo: f, Heads: f}
+}
+
+func (f *fakeRepo) Commit(sha string) (*github.Commit, error) {
+	return &github.Commit{SHA: github.String(sha), Message: github.String(f.messages[sha])}, nil
+}
+
+func (f *fakeRepo) EditPullRequest(num int, pr *github.PullRequest) (*github.PullRequest, error) {
+	f.edited[num] = pr
+	return &github.PullRequest{Number: github.Int(num), Title: pr.Title, Body: pr.Body}, nil
+}
+
+func (f *fakeRepo) RequestReviewers(num int, reviewers, teams []string) error {
+	f.reviewers[num] = append(f.reviewers[num], reviewers...)
+	return nil
+}
+
+func (f *fakeRepo) AddLabels(num int, labels []string) error {
+	f.labels[num] = append(f.labels[num], labels...)
+	return nil
+}
+
+const (
+	original = "Add widgets\n\nWidgets are added.\n"
+	reworded = "Add better widgets\n\nWidgets are added, and improved.\n\nReviewers: alice\nLabels: ui\nBug: 42\n"
+)
+
+func TestRecordedSource(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "no marker",
+			body: "Widgets are added.\n",
+			want: "",
+		},
+		{
+			name: "marker",
+			body: "Widgets are added.\n\n" + sourceMarker(original) + "\n",
+			want: messageHash(original),
+		},
+		{
+			name: "marker before navigation",
+			body: "Widgets are added.\n\n" + sourceMarker(original) + "\n\n" + stack.NavigationBegin + "\n" + stack.NavigationEnd + "\n",
+			want: messageHash(original),
+		},
+		{
+			name: "malformed marker",
+			body: sourceMarkerPrefix + "not-a-hash" + markerSuffix,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recordedSource(tt.body); got != tt.want {
+				t.Errorf("recordedSource(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPRText(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		wantTitle string
+		wantBody  string
+		wantMeta  *metadata
+		wantErr   bool
+	}{
+		{
+			name:      "body",
+			message:   original,
+			wantTitle: "Add widgets",
+			wantBody:  "Widgets are added.\n\n" + sourceMarker(original) + "\n",
+			wantMeta:  &metadata{},
+		},
+		{
+			name:      "metadata trailers are removed",
+			message:   reworded,
+			wantTitle: "Add better widgets",
+			wantBody:  "Widgets are added, and improved.\n\nBug: 42\n\n" + sourceMarker(reworded) + "\n",
+			wantMeta:  &metadata{reviewers: []string{"alice"}, labels: []string{"ui"}},
+		},
+		{
+			name:    "no body",
+			message: "Add widgets",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newFakeRepo(map[string]string{"sha": tt.message})
+			title, body, meta, err := prText(r, "sha")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if title != tt.wantTitle {
+				t.Errorf("prText() title = %q, want %q", title, tt.wantTitle)
+			}
+			if body != tt.wantBody {
+				t.Errorf("prText() body = %q, want %q", body, tt.wantBody)
+			}
+			if !reflect.DeepEqual(meta, tt.wantMeta) {
+				t.Errorf("prText() metadata = %+v, want %+v", meta, tt.wantMeta)
+			}
+		})
+	}
+}
+
+func TestSyncPR(t *testing.T) {
+	nav := stack.NavigationBegin + "\n**Stack** (bottom to top):\n" + stack.NavigationEnd
+	originalBody := "Widgets are added.\n\n" + sourceMarker(original) + "\n"
+	tests := []struct {
+		name          string
+		body          string
+		message       string
+		wantEdit      *github.PullRequest
+		wantReviewers []string
+		wantLabels    []string
+	}{
+		{
+			name:    "no recorded source",
+			body:    "Written on GitHub.\n",
+			message: reworded,
+		},
+		{
+			name:    "opted out",
+			body:    originalBody + noSyncMarker + "\n",
+			message: reworded,
+		},
+		{
+			name:    "unchanged",
+			body:    stack.ReplaceNavigation(originalBody, nav),
+			message: original,
+		},
+		{
+			name:    "first sync",
+			body:    originalBody,
+			message: reworded,
+			wantEdit: &github.PullRequest{
+				Title: github.String("Add better widgets"),
+				Body:  github.String("Widgets are added, and improved.\n\nBug: 42\n\n" + sourceMarker(reworded) + "\n"),
+			},
+			wantReviewers: []string{"alice"},
+			wantLabels:    []string{"ui"},
+		},
+		{
+			name:    "updated keeps navigation",
+			body:    stack.ReplaceNavigation(originalBody, nav),
+			message: reworded,
+			wantEdit: &github.PullRequest{
+				Title: github.String("Add better widgets"),
+				Body:  github.String(stack.ReplaceNavigation("Widgets are added, and improved.\n\nBug: 42\n\n"+sourceMarker(reworded)+"\n", nav)),
+			},
+			wantReviewers: []string{"alice"},
+			wantLabels:    []string{"ui"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newFakeRepo(map[string]string{"sha": tt.message})
+			f := r.Repo.(*fakeRepo)
+			pr := &github.PullRequest{Number: github.Int(1), Title: github.String("Add widgets"), Body: github.String(tt.body)}
+			if err := syncPR(r, pr, "sha"); err != nil {
+				t.Fatalf("syncPR() error = %v", err)
+			}
+			if got := f.edited[1]; !reflect.DeepEqual(got, tt.wantEdit) {
+				t.Errorf("syncPR() edited %+v, want %+v", got, tt.wantEdit)
+			}
+			if tt.wantEdit != nil && pr.GetBody() != tt.wantEdit.GetBody() {
+				t.Errorf("syncPR() left body %q, want %q", pr.GetBody(), tt.wantEdit.GetBody())
+			}
+			if got := f.reviewers[1]; !reflect.DeepEqual(got, tt.wantReviewers) {
+				t.Errorf("syncPR() requested reviewers %v, want %v", got, tt.wantReviewers)
+			}
+			if got := f.labels[1]; !reflect.DeepEqual(got, tt.wantLabels) {
+				t.Errorf("syncPR() added labels %v, want %v", got, tt.wantLabels)
+			}
+		})
+	}
+}
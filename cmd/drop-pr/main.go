@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/repo/backend"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/bretmckee/git-tools/pkg/urls"
+	"github.com/golang/glog"
+)
+
+// defaultComment explains why a pull request was closed when no -message is
+// given.
+const defaultComment = "Dropped from its stack; the pull requests based on it now target %s."
+
+// dropPR drops pull request `number` from its stack and prints the local
+// commits which have to be removed. They are printed to standard error, which
+// keeps standard output for a plan saved with `-plan -`.
+func dropPR(c repo.Repo, deleteBranch bool, number int, comment string) error {
+	pr, err := c.PullRequest(number)
+	if err != nil {
+		return fmt.Errorf("PR %d could not be read: %v", number, err)
+	}
+	// The commits are read first, since GitHub may no longer list them once
+	// the branch is deleted.
+	commits, err := c.PullRequestCommits(number)
+	if err != nil {
+		return fmt.Errorf("failed to get commits of PR %d: %v", number, err)
+	}
+	base := pr.GetBase().GetRef()
+	if comment == "" {
+		comment = fmt.Sprintf(defaultComment, base)
+	}
+	children, err := stack.Drop(c, pr, comment, deleteBranch)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Remove these commits of PR %d from your local branch:\n", number)
+	for _, rc := range commits {
+		subject := strings.SplitN(rc.GetCommit().GetMessage(), "\n", 2)[0]
+		fmt.Fprintf(os.Stderr, "\t%s %s\n", rc.GetSHA(), subject)
+	}
+	if len(children) > 0 {
+		fmt.Fprintf(os.Stderr, "The branches of the PRs based on it can be rebased without them with:\n")
+		for _, n := range children {
+			child, err := c.PullRequest(n)
+			if err != nil {
+				return fmt.Errorf("PR %d could not be read: %v", n, err)
+			}
+			fmt.Fprintf(os.Stderr, "\tgit rebase --onto %s %s %s\n", base, pr.GetHead().GetSHA(), child.GetHead().GetRef())
+		}
+	}
+	return nil
+}
+
+func main() {
+	var (
+		dryRun       = flag.Bool("dry-run", false, "Dry Run mode -- print the changes instead of making them")
+		baseURL      = flag.String("url", "", "GitHub Base URL")
+		configPath   = flag.String("config", config.DefaultPath(), "Path of the configuration file selecting the backend of the repo")
+		deleteBranch = flag.Bool("delete-branch", false, "Also delete the head branch of the dropped PR")
+		journalDir   = flag.String("journal", journal.Dir(), "Directory of the journals recording changes for undo-run (empty to disable)")
+		login        = flag.String("login", "", "Login of the user to drop for.")
+		message      = flag.String("message", "", "Comment explaining why the PR is closed (defaults to a note naming its new base)")
+		number       = flag.Int("pr", 0, "id of the pull request to drop")
+		planPath     = flag.String("plan", "", "Save the changes as a plan for apply-plan in this file (- for standard output) instead of making them")
+		sourceOwner  = flag.String("source-owner", "", "Name of the owner (user or org) of the repo to create the commit in.")
+		sourceRepo   = flag.String("source-repo", "", "Name of repo to create the commit in.")
+		token        = flag.String("token", "", "github auth token to use (also checks environment GITHUB_TOKEN")
+		uploadURL    = flag.String("upload", "", "GitHub Upload URL")
+	)
+	flag.Parse()
+	if *token == "" {
+		*token = os.Getenv("GITHUB_TOKEN")
+	}
+	if *token == "" {
+		glog.Exit("Unauthorized: No token present")
+	}
+	if *sourceOwner == "" || *sourceRepo == "" || *login == "" {
+		glog.Exitf("A non-empty value must be specified for the flags `-source-owner (=%q)`, `-source-repo (=%q)` and `-login (=%q)`", *sourceOwner, *sourceRepo, *login)
+	}
+	if *number <= 0 {
+		glog.Exit("An positive integer value must be specified for `-pr`")
+	}
+
+	b, u, err := urls.Get(*baseURL, *uploadURL)
+	if err != nil {
+		glog.Exitf("failed to get URLs: %v", err)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		glog.Exitf("failed to load config: %v", err)
+	}
+
+	c, err := backend.Create(cfg, b, u, *sourceOwner, *sourceRepo, *login, *token)
+	if err != nil {
+		glog.Exitf("failed to create client: %v", err)
+	}
+
+	var r repo.Repo = c
+	var rec *plan.Recorder
+	if *dryRun || *planPath != "" {
+		rec = plan.NewRecorder(c, *sourceOwner, *sourceRepo, "drop-pr")
+		r = rec
+	} else if *journalDir != "" {
+		r = journal.Wrap(c, journal.Open(journal.Path(*journalDir, *sourceOwner, *sourceRepo), "drop-pr"))
+	}
+	if err := dropPR(r, *deleteBranch, *number, *message); err != nil {
+		glog.Exitf("drop-pr failed: %v", err)
+	}
+	if rec != nil {
+		if err := rec.Plan.Report(*planPath); err != nil {
+			glog.Exitf("failed to report plan: %v", err)
+		}
+	}
+}
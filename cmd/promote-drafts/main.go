@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/repo/backend"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/bretmckee/git-tools/pkg/urls"
+	"github.com/golang/glog"
+)
+
+// promoteDrafts marks the draft pull requests of `author` (of anyone, if it is
+// empty) ready for review once they are the bottom of their stack, or once
+// their checks pass if `onGreen` is set. If `number` is positive, only the
+// stack containing it is considered.
+func promoteDrafts(c repo.Repo, onGreen bool, author string, number int) error {
+	prs, err := c.PullRequests()
+	if err != nil {
+		return fmt.Errorf("unable to get pull requests: %v", err)
+	}
+	if number > 0 {
+		// Whether a pull request is a bottom depends on the other pull
+		// requests, so this has to be done before narrowing the candidates.
+		if prs, err = stack.Find(prs, number); err != nil {
+			return fmt.Errorf("unable to find stack for PR %d: %v", number, err)
+		}
+	}
+	promoted, err := stack.Promote(c, prs, author, onGreen)
+	if err != nil {
+		return err
+	}
+	if len(promoted) == 0 {
+		glog.Info("no drafts are ready for review")
+	}
+	return nil
+}
+
+// convertToDraft converts pull request `number` back to a draft.
+func convertToDraft(c repo.Repo, number int) error {
+	pr, err := c.PullRequest(number)
+	if err != nil {
+		return fmt.Errorf("PR %d could not be read: %v", number, err)
+	}
+	if pr.GetDraft() {
+		glog.Infof("PR %d is already a draft", number)
+		return nil
+	}
+	if pr.GetState() != "open" {
+		return fmt.Errorf("PR %d is %s", number, stack.State(pr))
+	}
+	glog.Infof("converting PR %d to a draft", number)
+	return c.ConvertToDraft(number)
+}
+
+func main() {
+	var (
+		dryRun      = flag.Bool("dry-run", false, "Dry Run mode -- print the changes instead of making them")
+		baseURL     = flag.String("url", "", "GitHub Base URL")
+		all         = flag.Bool("all", false, "Promote the drafts of every author, not just those of -login")
+		configPath  = flag.String("config", config.DefaultPath(), "Path of the configuration file selecting the backend of the repo")
+		draft       = flag.Bool("draft", false, "Convert -pr back to a draft instead of promoting drafts")
+		journalDir  = flag.String("journal", journal.Dir(), "Directory of the journals recording changes for undo-run (empty to disable)")
+		login       = flag.String("login", "", "Login of the user whose drafts are promoted.")
+		number      = flag.Int("pr", 0, "id of any pull request in the stack to promote (0 promotes every stack)")
+		onGreen     = flag.Bool("on-green", true, "Also promote drafts in the middle of a stack once all of their checks pass")
+		planPath    = flag.String("plan", "", "Save the changes as a plan for apply-plan in this file (- for standard output) instead of making them")
+		sourceOwner = flag.String("source-owner", "", "Name of the owner (user or org) of the repo to create the commit in.")
+		sourceRepo  = flag.String("source-repo", "", "Name of repo to create the commit in.")
+		token       = flag.String("token", "", "github auth token to use (also checks environment GITHUB_TOKEN")
+		uploadURL   = flag.String("upload", "", "GitHub Upload URL")
+	)
+	flag.Parse()
+	if *token == "" {
+		*token = os.Getenv("GITHUB_TOKEN")
+	}
+	if *token == "" {
+		glog.Exit("Unauthorized: No token present")
+	}
+	if *sourceOwner == "" || *sourceRepo == "" || *login == "" {
+		glog.Exitf("A non-empty value must be specified for the flags `-source-owner (=%q)`, `-source-repo (=%q)` and `-login (=%q)`", *sourceOwner, *sourceRepo, *login)
+	}
+	if *draft && *number <= 0 {
+		glog.Exit("A positive integer value must be specified for `-pr` with `-draft`")
+	}
+
+	b, u, err := urls.Get(*baseURL, *uploadURL)
+	if err != nil {
+		glog.Exitf("failed to get URLs: %v", err)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		glog.Exitf("failed to load config: %v", err)
+	}
+
+	c, err := backend.Create(cfg, b, u, *sourceOwner, *sourceRepo, *login, *token)
+	if err != nil {
+		glog.Exitf("failed to create client: %v", err)
+	}
+
+	var r repo.Repo = c
+	var rec *plan.Recorder
+	if *dryRun || *planPath != "" {
+		rec = plan.NewRecorder(c, *sourceOwner, *sourceRepo, "promote-drafts")
+		r = rec
+	} else if *journalDir != "" {
+		r = journal.Wrap(c, journal.Open(journal.Path(*journalDir, *sourceOwner, *sourceRepo), "promote-drafts"))
+	}
+	author := *login
+	if *all {
+		author = ""
+	}
+	if *draft {
+		err = convertToDraft(r, *number)
+	} else {
+		err = promoteDrafts(r, *onGreen, author, *number)
+	}
+	if err != nil {
+		glog.Exitf("promote-drafts failed: %v", err)
+	}
+	if rec != nil {
+		if err := rec.Plan.Report(*planPath); err != nil {
+			glog.Exitf("failed to report plan: %v", err)
+		}
+	}
+}
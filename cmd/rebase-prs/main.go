@@ -1,53 +1,183 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
-	"github.com/bretmckee/git-tools/pkg/repo/client"
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/git"
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/repo/backend"
+	"github.com/bretmckee/git-tools/pkg/stack"
 	"github.com/bretmckee/git-tools/pkg/urls"
 	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
 )
 
-func rebasePRs(c *client.Client, dryRun bool, number int) error {
-	closedPR, err := c.PullRequest(number)
+// rebaser rebases the branches of retargeted pull requests onto their new base.
+// A nil rebaser does nothing.
+type rebaser struct {
+	g *git.Git
+	j *journal.Journal
+	// plan, if not nil, gets the pushes instead of the remote.
+	plan   *plan.Plan
+	remote string
+	// conflicts holds the numbers of the pull requests which could not be
+	// rebased because of conflicts.
+	conflicts []int
+}
+
+// rebase rebases the head branch of PR `number` onto `newBase`, dropping the
+// commits reachable from `upstream` (the last head of its old base, which were
+// squashed into `newBase`). Conflicts are recorded and are not an error, so the
+// remaining pull requests can still be rebased.
+func (rb *rebaser) rebase(c repo.Repo, number int, newBase, upstream string) error {
+	if rb == nil {
+		return nil
+	}
+	if upstream == "" {
+		glog.Warningf("not rebasing PR %d: the head of its old base is unknown", number)
+		return nil
+	}
+	pr, err := c.PullRequest(number)
 	if err != nil {
 		return fmt.Errorf("PR %d could not be read: %v", number, err)
 	}
+	branch := pr.GetHead().GetRef()
+	oldSHA, newSHA, err := rb.g.RebaseOnto(rb.remote, branch, newBase, upstream)
+	if err != nil {
+		if !errors.Is(err, git.ErrConflict) {
+			return fmt.Errorf("failed to rebase PR %d: %v", number, err)
+		}
+		glog.Warningf("PR %d (%s) needs to be rebased onto %s by hand: %v", number, branch, newBase, err)
+		rb.conflicts = append(rb.conflicts, number)
+		return nil
+	}
+	if oldSHA == newSHA {
+		return nil
+	}
+	if rb.plan != nil {
+		rb.plan.Add(plan.Op{Kind: plan.OpPush, Number: number, Ref: branch, Remote: rb.remote, SHA: oldSHA, To: newSHA})
+		return nil
+	}
+	if err := rb.g.Push(rb.remote, branch, oldSHA, newSHA); err != nil {
+		return fmt.Errorf("failed to push PR %d: %v", number, err)
+	}
+	glog.Infof("rebased %s onto %s (%s -> %s)", branch, newBase, oldSHA, newSHA)
+	if err := rb.j.Record(journal.Entry{Op: journal.OpPush, Number: number, Ref: branch, Before: oldSHA, After: newSHA}); err != nil {
+		glog.Warningf("failed to journal push of %s: %v", branch, err)
+	}
+	return nil
+}
+
+// err returns an error naming the pull requests which had conflicts, if any.
+func (rb *rebaser) err() error {
+	if rb == nil || len(rb.conflicts) == 0 {
+		return nil
+	}
+	var nums []string
+	for _, n := range rb.conflicts {
+		nums = append(nums, fmt.Sprintf("%d", n))
+	}
+	return fmt.Errorf("rebase conflicts in PRs %s were aborted", strings.Join(nums, ", "))
+}
+
+func rebasePRs(c repo.Repo, rb *rebaser, number int) ([]stack.Retargeting, error) {
+	closedPR, err := c.PullRequest(number)
+	if err != nil {
+		return nil, fmt.Errorf("PR %d could not be read: %v", number, err)
+	}
 	if !closedPR.GetMerged() {
-		return fmt.Errorf("PR %d has not been merged", number)
+		return nil, fmt.Errorf("PR %d has not been merged", number)
+	}
+	children, err := stack.Retarget(c, closedPR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retarget children of PR %d: %v", number, err)
+	}
+	var retargeted []stack.Retargeting
+	for _, child := range children {
+		retargeted = append(retargeted, stack.Retargeting{
+			Number:   child,
+			From:     closedPR.GetHead().GetRef(),
+			To:       closedPR.GetBase().GetRef(),
+			Upstream: closedPR.GetHead().GetSHA(),
+		})
+		if err := rb.rebase(c, child, closedPR.GetBase().GetRef(), closedPR.GetHead().GetSHA()); err != nil {
+			return retargeted, err
+		}
+	}
+	return retargeted, rb.err()
+}
+
+func sweepPRs(c repo.Repo, rb *rebaser) ([]stack.Retargeting, error) {
+	retargeted, err := stack.Sweep(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, rt := range retargeted {
+		if err := rb.rebase(c, rt.Number, rt.To, rt.Upstream); err != nil {
+			return retargeted, err
+		}
 	}
-	ref := closedPR.GetHead().GetRef()
-	newBase := closedPR.GetBase().GetRef()
+	return retargeted, rb.err()
+}
+
+// promoteDrafts marks the drafts of `author` among the `retargeted` pull
+// requests ready for review if they are now the bottom of their stack. Drafts
+// which this run did not retarget are left alone. The bases of the
+// `retargeted` pull requests are taken from the retargeting, since they have
+// not changed on GitHub when the changes are only planned.
+func promoteDrafts(c repo.Repo, retargeted []stack.Retargeting, author string) error {
 	prs, err := c.PullRequests()
 	if err != nil {
 		return fmt.Errorf("unable to get pull requests: %v", err)
 	}
-	for _, pr := range prs {
-		if pr.GetBase().GetRef() == ref {
-			if dryRun {
-				glog.Infof("PR %d matched branch %s, not changing base to %s because of dry run flag", pr.GetNumber(), ref, newBase)
-				continue
-			}
-			glog.Infof("PR %d matched branch %s, changing base to %s", pr.GetNumber(), ref, newBase)
-			if err := c.ChangePullRequestBase(pr.GetNumber(), newBase); err != nil {
-				return fmt.Errorf("failed to change base: %v", err)
-			}
+	bases := make(map[int]string)
+	for _, rt := range retargeted {
+		bases[rt.Number] = rt.To
+	}
+	for i, pr := range prs {
+		base, ok := bases[pr.GetNumber()]
+		switch {
+		case !ok && pr.GetDraft():
+			// Every pull request is needed to find the bottoms, so the
+			// drafts which are not candidates are shown as ready.
+			held := *pr
+			held.Draft = github.Bool(false)
+			prs[i] = &held
+		case ok && pr.GetBase().GetRef() != base:
+			moved := *pr
+			moved.Base = &github.PullRequestBranch{Ref: github.String(base), Repo: pr.GetBase().GetRepo()}
+			prs[i] = &moved
 		}
 	}
-
-	return nil
+	// The checks of the retargeted pull requests have only just restarted,
+	// so only the bottoms are promoted.
+	_, err = stack.Promote(c, prs, author, false)
+	return err
 }
 
 func main() {
 	var (
-		dryRun      = flag.Bool("dry-run", false, "Dry Run mode -- no pull requests will be created")
+		dryRun      = flag.Bool("dry-run", false, "Dry Run mode -- print the changes instead of making them")
 		baseURL     = flag.String("url", "", "GitHub Base URL")
+		configPath  = flag.String("config", config.DefaultPath(), "Path of the configuration file selecting the backend of the repo")
+		journalDir  = flag.String("journal", journal.Dir(), "Directory of the journals recording changes for undo-run (empty to disable)")
 		login       = flag.String("login", "", "Login of the user to submit for.")
 		number      = flag.Int("pr", 0, "id of the closed pull request to rebase around")
+		planPath    = flag.String("plan", "", "Save the changes as a plan for apply-plan in this file (- for standard output) instead of making them")
+		promote     = flag.Bool("promote", false, "Also mark the drafts of -login which this run retargets ready for review if they become the bottom of their stack")
+		rebase      = flag.Bool("rebase", false, "Also rebase the branches of retargeted PRs onto their new base and force push them (requires a local clone)")
+		remote      = flag.String("remote", "origin", "Name of the git remote for the repo, used with -rebase")
+		repoDir     = flag.String("repo-dir", ".", "Directory of the local clone, used with -rebase")
 		sourceOwner = flag.String("source-owner", "", "Name of the owner (user or org) of the repo to create the commit in.")
 		sourceRepo  = flag.String("source-repo", "", "Name of repo to create the commit in.")
+		sweep       = flag.Bool("sweep", false, "Retarget every open PR based on a merged or deleted PR's branch, instead of only the children of -pr")
 		token       = flag.String("token", "", "github auth token to use (also checks environment GITHUB_TOKEN")
 		uploadURL   = flag.String("upload", "", "GitHub Upload URL")
 	)
@@ -61,8 +191,8 @@ func main() {
 	if *sourceOwner == "" || *sourceRepo == "" || *login == "" {
 		glog.Exitf("A non-empty value must be specified for the flags `-source-owner (=%q)`, `-source-repo (=%q)` and `-login (=%q)`", *sourceOwner, *sourceRepo, *login)
 	}
-	if *number <= 0 {
-		glog.Exit("An positive integer value must be specified for `-pr`")
+	if *sweep == (*number > 0) {
+		glog.Exit("Exactly one of `-sweep` or a positive integer value for `-pr` must be specified")
 	}
 
 	b, u, err := urls.Get(*baseURL, *uploadURL)
@@ -70,12 +200,53 @@ func main() {
 		glog.Exitf("failed to get URLs: %v", err)
 	}
 
-	c, err := client.Create(b, u, *sourceOwner, *sourceRepo, *login, *token)
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		glog.Exitf("failed to load config: %v", err)
+	}
+
+	c, err := backend.Create(cfg, b, u, *sourceOwner, *sourceRepo, *login, *token)
 	if err != nil {
 		glog.Exitf("failed to create client: %v", err)
 	}
 
-	if err := rebasePRs(c, *dryRun, *number); err != nil {
-		glog.Exitf("rebasePRs failed: %v", err)
+	var r repo.Repo = c
+	var j *journal.Journal
+	var rec *plan.Recorder
+	if *dryRun || *planPath != "" {
+		rec = plan.NewRecorder(c, *sourceOwner, *sourceRepo, "rebase-prs")
+		r = rec
+	} else if *journalDir != "" {
+		j = journal.Open(journal.Path(*journalDir, *sourceOwner, *sourceRepo), "rebase-prs")
+		r = journal.Wrap(c, j)
+	}
+	var rb *rebaser
+	if *rebase {
+		rb = &rebaser{g: git.New(*repoDir), j: j, remote: *remote}
+		if rec != nil {
+			rb.plan = rec.Plan
+		}
+	}
+
+	var retargeted []stack.Retargeting
+	if *sweep {
+		retargeted, err = sweepPRs(r, rb)
+	} else {
+		retargeted, err = rebasePRs(r, rb, *number)
+	}
+	if *promote && len(retargeted) > 0 {
+		// Promote even if a rebase had conflicts, since the pull requests
+		// were still retargeted.
+		if err := promoteDrafts(r, retargeted, *login); err != nil {
+			glog.Errorf("failed to promote drafts: %v", err)
+		}
+	}
+	if err != nil {
+		glog.Exitf("rebase-prs failed: %v", err)
+	}
+	if rec != nil {
+		if err := rec.Plan.Report(*planPath); err != nil {
+			glog.Exitf("failed to report plan: %v", err)
+		}
 	}
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/google/go-github/v28/github"
+)
+
+// draftRepo serves a fixed list of open pull requests and records the ones
+// marked ready for review.
+type draftRepo struct {
+	repo.Repo
+	prs   []*github.PullRequest
+	ready []int
+}
+
+func (f *draftRepo) PullRequests() ([]*github.PullRequest, error) {
+	return f.prs, nil
+}
+
+func (f *draftRepo) MarkReadyForReview(num int) error {
+	f.ready = append(f.ready, num)
+	return nil
+}
+
+func draft(num int, head, base, author string) *github.PullRequest {
+	r := &github.Repository{FullName: github.String("o/r")}
+	return &github.PullRequest{
+		Number: github.Int(num),
+		State:  github.String("open"),
+		Draft:  github.Bool(true),
+		User:   &github.User{Login: github.String(author)},
+		Head:   &github.PullRequestBranch{Ref: github.String(head), Repo: r},
+		Base:   &github.PullRequestBranch{Ref: github.String(base), Repo: r},
+	}
+}
+
+func TestPromoteDrafts(t *testing.T) {
+	f := &draftRepo{prs: []*github.PullRequest{
+		// An untouched bottom draft, which was not retargeted by the run.
+		draft(1, "a", "master", "me"),
+		// Retargeted from the merged PR 2, but still based on it on
+		// GitHub since the run only planned the change.
+		draft(3, "c", "b", "me"),
+		draft(4, "d", "c", "me"),
+		draft(5, "e", "master", "other"),
+	}}
+	retargeted := []stack.Retargeting{
+		{Number: 3, From: "b", To: "master"},
+		{Number: 5, From: "x", To: "master"},
+	}
+	if err := promoteDrafts(f, retargeted, "me"); err != nil {
+		t.Fatalf("promoteDrafts() error = %v", err)
+	}
+	if want := []int{3}; !reflect.DeepEqual(f.ready, want) {
+		t.Errorf("promoteDrafts() marked %v ready, want %v", f.ready, want)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/repo/backend"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/bretmckee/git-tools/pkg/urls"
+	"github.com/golang/glog"
+)
+
+func refreshStack(c repo.Repo, number int) error {
+	prs, err := c.PullRequests()
+	if err != nil {
+		return fmt.Errorf("unable to get pull requests: %v", err)
+	}
+	s, err := stack.Find(prs, number)
+	if err != nil {
+		return fmt.Errorf("unable to find stack for PR %d: %v", number, err)
+	}
+	glog.V(2).Infof("stack for PR %d has %d pull requests", number, len(s))
+	if err := stack.UpdateNavigation(c, s); err != nil {
+		return fmt.Errorf("failed to update navigation: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	var (
+		dryRun      = flag.Bool("dry-run", false, "Dry Run mode -- print the changes instead of making them")
+		baseURL     = flag.String("url", "", "GitHub Base URL")
+		configPath  = flag.String("config", config.DefaultPath(), "Path of the configuration file selecting the backend of the repo")
+		journalDir  = flag.String("journal", journal.Dir(), "Directory of the journals recording changes for undo-run (empty to disable)")
+		login       = flag.String("login", "", "Login of the user to refresh for.")
+		number      = flag.Int("pr", 0, "id of any open pull request in the stack to refresh")
+		planPath    = flag.String("plan", "", "Save the changes as a plan for apply-plan in this file (- for standard output) instead of making them")
+		sourceOwner = flag.String("source-owner", "", "Name of the owner (user or org) of the repo to create the commit in.")
+		sourceRepo  = flag.String("source-repo", "", "Name of repo to create the commit in.")
+		token       = flag.String("token", "", "github auth token to use (also checks environment GITHUB_TOKEN")
+		uploadURL   = flag.String("upload", "", "GitHub Upload URL")
+	)
+	flag.Parse()
+	if *token == "" {
+		*token = os.Getenv("GITHUB_TOKEN")
+	}
+	if *token == "" {
+		glog.Exit("Unauthorized: No token present")
+	}
+	if *sourceOwner == "" || *sourceRepo == "" || *login == "" {
+		glog.Exitf("A non-empty value must be specified for the flags `-source-owner (=%q)`, `-source-repo (=%q)` and `-login (=%q)`", *sourceOwner, *sourceRepo, *login)
+	}
+	if *number <= 0 {
+		glog.Exit("An positive integer value must be specified for `-pr`")
+	}
+
+	b, u, err := urls.Get(*baseURL, *uploadURL)
+	if err != nil {
+		glog.Exitf("failed to get URLs: %v", err)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		glog.Exitf("failed to load config: %v", err)
+	}
+
+	c, err := backend.Create(cfg, b, u, *sourceOwner, *sourceRepo, *login, *token)
+	if err != nil {
+		glog.Exitf("failed to create client: %v", err)
+	}
+
+	var r repo.Repo = c
+	var rec *plan.Recorder
+	if *dryRun || *planPath != "" {
+		rec = plan.NewRecorder(c, *sourceOwner, *sourceRepo, "refresh-stack")
+		r = rec
+	} else if *journalDir != "" {
+		r = journal.Wrap(c, journal.Open(journal.Path(*journalDir, *sourceOwner, *sourceRepo), "refresh-stack"))
+	}
+	if err := refreshStack(r, *number); err != nil {
+		glog.Exitf("refreshStack failed: %v", err)
+	}
+	if rec != nil {
+		if err := rec.Plan.Report(*planPath); err != nil {
+			glog.Exitf("failed to report plan: %v", err)
+		}
+	}
+}
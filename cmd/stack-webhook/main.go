@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/repo/client"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/bretmckee/git-tools/pkg/state"
+	"github.com/bretmckee/git-tools/pkg/urls"
+	"github.com/bretmckee/git-tools/pkg/webhook"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// receiver retargets the children of pull requests as they are merged.
+type receiver struct {
+	// mu serializes deliveries, so redeliveries racing the original are
+	// handled once.
+	mu sync.Mutex
+	st *state.State
+	// dryRun makes each delivery plan its changes and print the plan
+	// instead of making them.
+	dryRun bool
+	// journalDir is the directory of the journals, or empty to not keep
+	// them.
+	journalDir string
+	baseURL    string
+	upload     string
+	login      string
+	token      string
+	clients    map[string]repo.Repo
+}
+
+// client returns the client for repository `r`.
+func (rc *receiver) client(r *github.Repository) (repo.Repo, error) {
+	name := r.GetFullName()
+	if c, ok := rc.clients[name]; ok {
+		return c, nil
+	}
+	c, err := client.Create(rc.baseURL, rc.upload, r.GetOwner().GetLogin(), r.GetName(), rc.login, rc.token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for %s: %v", name, err)
+	}
+	var j *journal.Journal
+	if rc.journalDir != "" && !rc.dryRun {
+		j = journal.Open(journal.Path(rc.journalDir, r.GetOwner().GetLogin(), r.GetName()), "stack-webhook")
+	}
+	rc.clients[name] = journal.Wrap(c, j)
+	return rc.clients[name], nil
+}
+
+// pullRequest handles pull_request event `ev` from delivery `delivery`. When a
+// pull request is merged the pull requests based on it are retargeted, as
+// rebase-prs does. Deliveries which were already handled are ignored; an empty
+// delivery (a replayed payload) is always handled.
+func (rc *receiver) pullRequest(delivery string, ev *github.PullRequestEvent) error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	pr := ev.GetPullRequest()
+	name := ev.GetRepo().GetFullName()
+	if ev.GetAction() != "closed" || !pr.GetMerged() {
+		glog.V(1).Infof("ignoring %s of %s PR %d", ev.GetAction(), name, pr.GetNumber())
+		return nil
+	}
+	k := "delivery:" + delivery
+	if delivery != "" && rc.st.Did(k) {
+		glog.Infof("delivery %s for %s PR %d was already handled", delivery, name, pr.GetNumber())
+		return nil
+	}
+	c, err := rc.client(ev.GetRepo())
+	if err != nil {
+		return err
+	}
+	var rec *plan.Recorder
+	if rc.dryRun {
+		rec = plan.NewRecorder(c, ev.GetRepo().GetOwner().GetLogin(), ev.GetRepo().GetName(), "stack-webhook")
+		c = rec
+	}
+	glog.Infof("%s PR %d was merged, retargeting its children", name, pr.GetNumber())
+	if _, err := stack.Retarget(c, pr); err != nil {
+		return fmt.Errorf("failed to retarget children of %s PR %d: %v", name, pr.GetNumber(), err)
+	}
+	if rec != nil {
+		return rec.Plan.Print(os.Stdout)
+	}
+	if delivery == "" {
+		return nil
+	}
+	return rc.st.Record(k)
+}
+
+// replay handles the pull_request event payloads saved in `paths`.
+func (rc *receiver) replay(paths []string) error {
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read payload: %v", err)
+		}
+		ev := &github.PullRequestEvent{}
+		if err := json.Unmarshal(data, ev); err != nil {
+			return fmt.Errorf("failed to parse payload %s: %v", path, err)
+		}
+		if err := rc.pullRequest("", ev); err != nil {
+			return fmt.Errorf("replay of %s failed: %v", path, err)
+		}
+	}
+	return nil
+}
+
+func main() {
+	var (
+		addr       = flag.String("addr", ":8080", "Address to listen for webhook deliveries on")
+		dryRun     = flag.Bool("dry-run", false, "Dry Run mode -- print the changes instead of making them")
+		baseURL    = flag.String("url", "", "GitHub Base URL")
+		journalDir = flag.String("journal", journal.Dir(), "Directory of the journals recording changes for undo-run (empty to disable)")
+		login      = flag.String("login", "", "Login of the user to retarget for.")
+		replay     = flag.Bool("replay", false, "Handle the pull_request payload files named by the arguments instead of listening")
+		secret     = flag.String("secret", "", "webhook secret (also checks environment GITHUB_WEBHOOK_SECRET)")
+		statePath  = flag.String("state", state.DefaultPath("stack-webhook"), "Path of the file recording the deliveries already handled")
+		token      = flag.String("token", "", "github auth token to use (also checks environment GITHUB_TOKEN")
+		uploadURL  = flag.String("upload", "", "GitHub Upload URL")
+	)
+	flag.Parse()
+	if *token == "" {
+		*token = os.Getenv("GITHUB_TOKEN")
+	}
+	if *token == "" {
+		glog.Exit("Unauthorized: No token present")
+	}
+	if *secret == "" {
+		*secret = os.Getenv("GITHUB_WEBHOOK_SECRET")
+	}
+	if *login == "" {
+		glog.Exitf("A non-empty value must be specified for the flag `-login (=%q)`", *login)
+	}
+	if *replay == (flag.NArg() == 0) {
+		glog.Exit("Payload files must be given with, and only with, `-replay`")
+	}
+	if !*replay && *secret == "" {
+		glog.Exit("A webhook secret must be specified with `-secret` or GITHUB_WEBHOOK_SECRET")
+	}
+
+	st, err := state.Load(*statePath)
+	if err != nil {
+		glog.Exitf("failed to load state: %v", err)
+	}
+
+	b, u, err := urls.Get(*baseURL, *uploadURL)
+	if err != nil {
+		glog.Exitf("failed to get URLs: %v", err)
+	}
+
+	rc := &receiver{
+		st:         st,
+		dryRun:     *dryRun,
+		journalDir: *journalDir,
+		baseURL:    b,
+		upload:     u,
+		login:      *login,
+		token:      *token,
+		clients:    make(map[string]repo.Repo),
+	}
+	if *replay {
+		if err := rc.replay(flag.Args()); err != nil {
+			glog.Exitf("replay failed: %v", err)
+		}
+		return
+	}
+
+	h := &webhook.Handler{Secret: []byte(*secret), PullRequest: rc.pullRequest}
+	glog.Infof("listening on %s", *addr)
+	glog.Exit(http.ListenAndServe(*addr, h))
+}
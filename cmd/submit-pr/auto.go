@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/review"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/golang/glog"
+)
+
+// enableAutoMerge enables auto-merge of pull request `number` with `method` and the
+// generated submit message, leaving GitHub to merge it once it is approved and
+// CI passes.
+func enableAutoMerge(c repo.Repo, cfg *config.Repo, baseBranch string, number int, method string, mo msgOptions) error {
+	pr, err := c.PullRequest(number)
+	if err != nil {
+		return fmt.Errorf("enableAutoMerge: failed to get %d: %v", number, err)
+	}
+	if pr.GetMerged() {
+		glog.Warningf("PR %d is already merged.", number)
+		return nil
+	}
+	if prRef := pr.GetBase().GetRef(); prRef != baseBranch {
+		return fmt.Errorf("pr base ref (%q) does not match base branch ref (%q)", prRef, baseBranch)
+	}
+	reviews, err := c.Reviews(number)
+	if err != nil {
+		return fmt.Errorf("enableAutoMerge failed to get reviews: %v", err)
+	}
+	approvers := review.Decide(reviews, 0, pr.GetUser().GetLogin()).Approvers
+	msg, err := submitMsg(c, cfg, pr, mo, approvers)
+	if err != nil {
+		return fmt.Errorf("enableAutoMerge failed to build submitMsg: %v", err)
+	}
+	if err := c.EnableAutoMerge(number, pr.GetHead().GetSHA(), method, msg); err != nil {
+		return fmt.Errorf("failed to enable auto-merge: %v", err)
+	}
+	glog.Infof("Enabled auto-merge (%s) for %d", method, number)
+	return nil
+}
+
+// disableAutoMerge cancels auto-merge of pull request `number`.
+func disableAutoMerge(c repo.Repo, number int) error {
+	if err := c.DisableAutoMerge(number); err != nil {
+		return fmt.Errorf("failed to disable auto-merge: %v", err)
+	}
+	glog.Infof("Disabled auto-merge for %d", number)
+	return nil
+}
+
+// reportAutoMerge writes the auto-merge status of every pull request in the
+// stack containing `number` to `w`.
+func reportAutoMerge(w io.Writer, c repo.Repo, number int) error {
+	prs, err := c.PullRequests()
+	if err != nil {
+		return fmt.Errorf("unable to get pull requests: %v", err)
+	}
+	s, err := stack.Find(prs, number)
+	if err != nil {
+		return fmt.Errorf("unable to find stack for PR %d: %v", number, err)
+	}
+	for _, pr := range s {
+		st, err := c.AutoMerge(pr.GetNumber())
+		if err != nil {
+			return err
+		}
+		state := "off"
+		if st.Enabled {
+			state = fmt.Sprintf("%s, enabled by %s at %s", st.Method, st.EnabledBy, st.EnabledAt.Format("2006-01-02 15:04"))
+		}
+		fmt.Fprintf(w, "#%d %s: auto-merge %s\n", pr.GetNumber(), pr.GetTitle(), state)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/google/go-github/v28/github"
+)
+
+// autoRepo serves a fixed set of single-commit pull requests and their
+// auto-merge status, and records the pull requests whose auto-merge is enabled
+// or disabled.
+type autoRepo struct {
+	repo.Repo
+	prs      []*github.PullRequest
+	status   map[int]*repo.AutoMergeStatus
+	enabled  []string
+	disabled []int
+}
+
+func (f *autoRepo) PullRequests() ([]*github.PullRequest, error) {
+	return f.prs, nil
+}
+
+func (f *autoRepo) PullRequest(num int) (*github.PullRequest, error) {
+	for _, pr := range f.prs {
+		if pr.GetNumber() == num {
+			return pr, nil
+		}
+	}
+	return nil, fmt.Errorf("no PR %d", num)
+}
+
+func (f *autoRepo) Reviews(num int) ([]*github.PullRequestReview, error) {
+	return nil, nil
+}
+
+func (f *autoRepo) PullRequestCommits(num int) ([]*github.RepositoryCommit, error) {
+	return []*github.RepositoryCommit{{SHA: github.String("c1"), Commit: &github.Commit{Message: github.String("commit")}}}, nil
+}
+
+func (f *autoRepo) EnableAutoMerge(num int, sha, method, msg string) error {
+	f.enabled = append(f.enabled, fmt.Sprintf("%d %s %s %q", num, sha, method, msg))
+	return nil
+}
+
+func (f *autoRepo) DisableAutoMerge(num int) error {
+	f.disabled = append(f.disabled, num)
+	return nil
+}
+
+func (f *autoRepo) AutoMerge(num int) (*repo.AutoMergeStatus, error) {
+	if st, ok := f.status[num]; ok {
+		return st, nil
+	}
+	return &repo.AutoMergeStatus{}, nil
+}
+
+func autoPR(num int, head, base string) *github.PullRequest {
+	r := &github.Repository{FullName: github.String("o/r")}
+	return &github.PullRequest{
+		Number: github.Int(num),
+		Title:  github.String(fmt.Sprintf("PR %d", num)),
+		Body:   github.String(fmt.Sprintf("body %d", num)),
+		State:  github.String("open"),
+		User:   &github.User{Login: github.String("me")},
+		Head:   &github.PullRequestBranch{Ref: github.String(head), SHA: github.String(head + "-sha"), Repo: r},
+		Base:   &github.PullRequestBranch{Ref: github.String(base), Repo: r},
+	}
+}
+
+func TestEnableAutoMerge(t *testing.T) {
+	merged := autoPR(3, "c", "master")
+	merged.Merged = github.Bool(true)
+	f := &autoRepo{prs: []*github.PullRequest{autoPR(1, "a", "master"), autoPR(2, "b", "a"), merged}}
+	tests := []struct {
+		name        string
+		number      int
+		wantEnabled []string
+		wantErr     string
+	}{
+		{
+			name:        "enabled with the submit message",
+			number:      1,
+			wantEnabled: []string{`1 a-sha squash "body 1\n"`},
+		},
+		{
+			name:    "not based on the base branch",
+			number:  2,
+			wantErr: `pr base ref ("a") does not match base branch ref ("master")`,
+		},
+		{
+			name:   "already merged",
+			number: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.enabled = nil
+			err := enableAutoMerge(f, &config.Repo{}, "master", tt.number, "squash", msgOptions{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("enableAutoMerge() error = %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("enableAutoMerge() error = %v, want %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(f.enabled, tt.wantEnabled) {
+				t.Errorf("enableAutoMerge() enabled %v, want %v", f.enabled, tt.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestDisableAutoMerge(t *testing.T) {
+	f := &autoRepo{}
+	if err := disableAutoMerge(f, 2); err != nil {
+		t.Fatalf("disableAutoMerge() error = %v", err)
+	}
+	if want := []int{2}; !reflect.DeepEqual(f.disabled, want) {
+		t.Errorf("disableAutoMerge() disabled %v, want %v", f.disabled, want)
+	}
+}
+
+func TestReportAutoMerge(t *testing.T) {
+	f := &autoRepo{
+		prs: []*github.PullRequest{autoPR(1, "a", "master"), autoPR(2, "b", "a"), autoPR(3, "c", "master")},
+		status: map[int]*repo.AutoMergeStatus{
+			1: {Enabled: true, Method: "squash", EnabledBy: "me", EnabledAt: time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC)},
+		},
+	}
+	var out bytes.Buffer
+	if err := reportAutoMerge(&out, f, 2); err != nil {
+		t.Fatalf("reportAutoMerge() error = %v", err)
+	}
+	want := "#1 PR 1: auto-merge squash, enabled by me at 2026-10-19 09:30\n" +
+		"#2 PR 2: auto-merge off\n"
+	if got := out.String(); got != want {
+		t.Errorf("reportAutoMerge() wrote\n%s\nwant\n%s", got, want)
+	}
+}
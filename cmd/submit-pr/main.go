@@ -6,48 +6,40 @@ import (
 	"os"
 	"time"
 
-	"github.com/bretmckee/git-tools/pkg/repo/client"
+	"github.com/bretmckee/git-tools/pkg/ci"
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/repo/backend"
+	"github.com/bretmckee/git-tools/pkg/review"
 	"github.com/bretmckee/git-tools/pkg/urls"
 	"github.com/golang/glog"
 	"github.com/google/go-github/v28/github"
-	"github.com/kr/pretty"
 )
 
-const (
-	maxCommitChainLength = 20
-)
-
-func submitMsg(c *client.Client, prBody string, first, last string) (string, error) {
-	msg := ""
-	l := 0
-	glog.V(2).Infof("submitMsg begins first=%s, last=%s", first, last)
-	for pos := first; pos != last; l++ {
-		commit, err := c.Commit(pos)
-		if err != nil {
-			return "", fmt.Errorf("submitMsg: failed to retrieve commit: %v", err)
-		}
-		glog.V(2).Infof("submitMsg processes commit: %s", pretty.Sprintf("%# v", commit))
-		if parents := len(commit.Parents); parents != 1 {
-			return "", fmt.Errorf("submitMsg: commit %s has %d parents", pos, parents)
-		}
-
-		msg = "* " + commit.GetMessage() + "\n\n" + msg
-		glog.V(2).Infof("after commit %s, msg=[%v]", pos, msg)
-		pos = *commit.Parents[0].SHA
-		if l >= maxCommitChainLength {
-			return "", fmt.Errorf("submitMsg: max chain length (%d) exceeded", maxCommitChainLength)
-		}
+// checkReviews returns the review decision for pull request `pr`, and an error
+// unless it has the approvals it requires and no outstanding requests for
+// changes.
+func checkReviews(c repo.Repo, cfg *config.Repo, p *github.Protection, pr *github.PullRequest) (*review.Result, error) {
+	number := pr.GetNumber()
+	required := review.Required(cfg.RequiredApprovals, p)
+	reviews, err := c.Reviews(number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %v", err)
 	}
-
-	// If there are fewer than two commits, just use the pr body as the message.
-	if l < 2 {
-		return prBody, nil
+	res := review.Decide(reviews, required, pr.GetUser().GetLogin())
+	glog.V(1).Infof("pr %d review decision %s: approvers=%v blockers=%v required=%d", number, res.Decision, res.Approvers, res.Blockers, res.Required)
+	switch res.Decision {
+	case review.ChangesRequested:
+		return res, fmt.Errorf("pr %d has changes requested by %v", number, res.Blockers)
+	case review.ReviewRequired:
+		return res, fmt.Errorf("pr %d has %d of %d required approvals", number, len(res.Approvers), res.Required)
 	}
-	return msg, nil
+	return res, nil
 }
 
-func submitPR(c *client.Client, dryRun, force bool, baseBranch string, number int, method string) error {
-	const retrySeconds = 60
+func submitPR(c repo.Repo, cfg *config.Repo, wait waitOptions, force bool, baseBranch string, number int, method string, mo msgOptions) error {
 	pr, err := c.PullRequest(number)
 	if err != nil {
 		return fmt.Errorf("submitPR: failed to get %d: %v", number, err)
@@ -74,40 +66,52 @@ func submitPR(c *client.Client, dryRun, force bool, baseBranch string, number in
 		}
 		glog.Warningf("because force was specified, ignoring error %v", err)
 	}
-	ref := pr.GetHead().GetRef()
-	var status *github.CombinedStatus
-	for {
-		// TODO(bretmckee): Consider an argument to terminate this loop after a
-		// timeout.
-		status, err = c.CombinedStatus(ref)
-		if err != nil {
-			return fmt.Errorf("submitPR: failed to get combined status: %v", err)
-		}
-		if status.GetState() != "pending" {
-			break
+	p, err := c.BranchProtection(baseBranch)
+	if err != nil {
+		return fmt.Errorf("failed to get protection for %q: %v", baseBranch, err)
+	}
+	reviewed, err := checkReviews(c, cfg, p, pr)
+	if err != nil {
+		if !force || reviewed == nil {
+			return err
 		}
-		if force {
-			glog.Warningf("bPR is pending, but not waiting because force was specified")
-			break
+		glog.Warningf("because force was specified, ignoring error %v", err)
+	}
+	ref := pr.GetHead().GetRef()
+	verdict, err := waitForCI(c, wait, number, ref, ci.RequiredContexts(p), force)
+	if err != nil {
+		return fmt.Errorf("submitPR: %w", err)
+	}
+	for _, o := range verdict.Optional {
+		if o.State == ci.Failure {
+			glog.Warningf("pr %d has failed optional %v", number, o)
 		}
-		glog.Warningf("pr %d status is pending: waiting %d seconds", number, retrySeconds)
-		time.Sleep(time.Second * retrySeconds)
 	}
-	if state := status.GetState(); state == "failure" {
-		err := fmt.Errorf("pr %d cannot be submitted because it has status %s", number, state)
+	if len(verdict.Missing) > 0 {
+		glog.Warningf("pr %d is missing required checks %v, which have never reported", number, verdict.Missing)
+	}
+	if state := verdict.State; state == ci.Failure {
+		err := fmt.Errorf("pr %d cannot be submitted because it has status %s: %v", number, state, verdict.Matching(ci.Failure))
 		if !force {
 			return err
 		}
 		glog.Warningf("because force was specified, ignoring error %v", err)
 	}
-	// TODO(bretmckee): Consider adding a way to specify a message.
-	msg, err := submitMsg(c, *pr.Body, pr.GetHead().GetSHA(), pr.GetBase().GetSHA())
+	msg, err := submitMsg(c, cfg, pr, mo, reviewed.Approvers)
 	if err != nil {
 		return fmt.Errorf("submitPR failed to build summitMsg: %v", err)
 	}
-	if dryRun {
-		glog.Warningf("skipping submission of %d because a dry run was requested", number)
-		return nil
+	queued, err := c.MergeQueueEnabled(baseBranch)
+	if err != nil {
+		// Older GitHub Enterprise servers do not support merge queues at all.
+		glog.Warningf("unable to check for a merge queue, merging directly: %v", err)
+		queued = false
+	}
+	if queued {
+		// The merge queue uses the merge method and message configured for
+		// the branch.
+		glog.V(1).Infof("%q uses a merge queue, ignoring method %q and message", baseBranch, method)
+		return submitQueued(c, wait, number, pr.GetHead().GetSHA())
 	}
 	if _, err := c.MergePullRequest(number, pr.GetHead().GetSHA(), method, msg); err != nil {
 		return fmt.Errorf("failed to submit PR %d: %v", number, err)
@@ -118,17 +122,30 @@ func submitPR(c *client.Client, dryRun, force bool, baseBranch string, number in
 
 func main() {
 	var (
+		auto        = flag.Bool("auto", false, "Enable auto-merge of -pr instead of waiting to merge it")
+		autoStatus  = flag.Bool("auto-status", false, "Report the auto-merge status of each PR in the stack containing -pr")
 		baseBranch  = flag.String("base", "master", "Base branch")
 		baseURL     = flag.String("url", "", "GitHub Base URL")
-		dryRun      = flag.Bool("dry-run", false, "Dry Run mode -- no pull requests will be created")
+		configPath  = flag.String("config", config.DefaultPath(), "Path of the configuration file")
+		credit      = flag.Bool("credit", true, "Add Reviewed-by and Co-authored-by trailers to the merge commit message")
+		disableAuto = flag.Bool("disable-auto", false, "Disable auto-merge of -pr")
+		dryRun      = flag.Bool("dry-run", false, "Dry Run mode -- print the changes instead of making them")
 		force       = flag.Bool("force", false, "Submit even if not fully approved.")
+		journalDir  = flag.String("journal", journal.Dir(), "Directory of the journals recording changes for undo-run (empty to disable)")
 		login       = flag.String("login", "", "Login of the user to submit for.")
 		method      = flag.String("method", "squash", "github merge method -- [merge|rebase|squash]")
+		msgFile     = flag.String("message-file", "", "File containing the merge commit message, instead of the template")
+		planPath    = flag.String("plan", "", "Save the changes as a plan for apply-plan in this file (- for standard output) instead of making them")
+		pollMax     = flag.Duration("poll-max-interval", 2*time.Minute, "Longest interval between CI polls")
+		pollMin     = flag.Duration("poll-interval", 10*time.Second, "Initial interval between CI polls")
 		pr          = flag.Int("pr", 0, "id of the pull request to submit")
+		settle      = flag.Duration("settle", 30*time.Second, "With -stack, time to let CI restart after retargeting a PR")
 		sourceOwner = flag.String("source-owner", "", "Name of the owner (user or org) of the repo to create the commit in.")
 		sourceRepo  = flag.String("source-repo", "", "Name of repo to create the commit in.")
+		submitAll   = flag.Bool("stack", false, "Submit the stack containing -pr, from the bottom up to and including -pr")
 		token       = flag.String("token", "", "github auth token to use (also checks environment GITHUB_TOKEN")
 		uploadURL   = flag.String("upload", "", "GitHub Upload URL")
+		waitTimeout = flag.Duration("wait-timeout", 0, "Longest time to wait for pending CI (0 waits forever)")
 	)
 	flag.Parse()
 	if *token == "" {
@@ -143,18 +160,80 @@ func main() {
 	if *pr <= 0 {
 		glog.Exit("An positive integer value must be specified for `-pr`")
 	}
+	modes := 0
+	for _, m := range []bool{*auto, *autoStatus, *disableAuto, *submitAll} {
+		if m {
+			modes++
+		}
+	}
+	if modes > 1 {
+		glog.Exit("At most one of `-auto`, `-auto-status`, `-disable-auto` and `-stack` may be specified")
+	}
+	if *submitAll && *planPath != "" {
+		// Only the bottom of a stack can be checked before it is merged.
+		glog.Exit("`-plan` cannot be used with `-stack`")
+	}
+	if *submitAll && *msgFile != "" {
+		// Each pull request of a stack needs its own message.
+		glog.Exit("`-message-file` cannot be used with `-stack`")
+	}
+	if *pollMin <= 0 || *pollMax < *pollMin {
+		glog.Exitf("`-poll-interval` (=%v) must be positive and no more than `-poll-max-interval` (=%v)", *pollMin, *pollMax)
+	}
 
 	b, u, err := urls.Get(*baseURL, *uploadURL)
 	if err != nil {
 		glog.Exitf("failed to get URLs: %v", err)
 	}
 
-	c, err := client.Create(b, u, *sourceOwner, *sourceRepo, *login, *token)
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		glog.Exitf("failed to load config: %v", err)
+	}
+
+	cl, err := backend.Create(cfg, b, u, *sourceOwner, *sourceRepo, *login, *token)
 	if err != nil {
 		glog.Exitf("failed to create client: %v", err)
 	}
+	var c repo.Repo = cl
+	var rec *plan.Recorder
+	if *dryRun || *planPath != "" {
+		rec = plan.NewRecorder(cl, *sourceOwner, *sourceRepo, "submit-pr")
+		c = rec
+	} else if *journalDir != "" {
+		c = journal.Wrap(cl, journal.Open(journal.Path(*journalDir, *sourceOwner, *sourceRepo), "submit-pr"))
+	}
 
-	if err := submitPR(c, *dryRun, *force, *baseBranch, *pr, *method); err != nil {
-		glog.Exitf("submitPR failed: %v", err)
+	wait := waitOptions{
+		timeout:     *waitTimeout,
+		minInterval: *pollMin,
+		maxInterval: *pollMax,
+	}
+	rc := cfg.Repo(*sourceOwner, *sourceRepo)
+	mo := msgOptions{
+		file:   *msgFile,
+		credit: *credit,
+	}
+	switch {
+	case *auto:
+		err = enableAutoMerge(c, rc, *baseBranch, *pr, *method, mo)
+	case *disableAuto:
+		err = disableAutoMerge(c, *pr)
+	case *autoStatus:
+		err = reportAutoMerge(os.Stdout, c, *pr)
+	case *submitAll:
+		err = submitStack(c, rc, wait, *force, *baseBranch, *pr, *method, mo, *settle)
+	default:
+		err = submitPR(c, rc, wait, *force, *baseBranch, *pr, *method, mo)
+	}
+	if err != nil {
+		glog.Errorf("submitPR failed: %v", err)
+		glog.Flush()
+		os.Exit(exitCode(err))
+	}
+	if rec != nil {
+		if err := rec.Plan.Report(*planPath); err != nil {
+			glog.Exitf("failed to report plan: %v", err)
+		}
 	}
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/message"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// msgOptions controls how submit-pr builds merge commit messages.
+type msgOptions struct {
+	// file, if set, holds the message to use instead of the template.
+	file string
+	// credit adds Reviewed-by and Co-authored-by trailers to templated
+	// messages.
+	credit bool
+}
+
+// submitMsg returns the commit message for submitting `pr`. It is the contents
+// of the message file if one was given, and otherwise the configured template
+// (or message.DefaultTemplate) executed for the pull request, with credit
+// trailers for `approvers` and the commit authors if requested.
+func submitMsg(c repo.Repo, cfg *config.Repo, pr *github.PullRequest, mo msgOptions, approvers []string) (string, error) {
+	if mo.file != "" {
+		data, err := ioutil.ReadFile(mo.file)
+		if err != nil {
+			return "", fmt.Errorf("submitMsg: failed to read message file: %v", err)
+		}
+		return string(data), nil
+	}
+
+	msg, err := message.ForPullRequest(c, cfg.MessageTemplate, pr, approvers, mo.credit)
+	if err != nil {
+		return "", fmt.Errorf("submitMsg: %v", err)
+	}
+	glog.V(2).Infof("submitMsg for %d: [%v]", pr.GetNumber(), msg)
+	return msg, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+)
+
+// submitQueued adds pull request `number` to the merge queue and waits until
+// it has been merged or removed from the queue. When planning it only plans
+// adding the pull request, since the queue will never move.
+func submitQueued(c repo.Repo, o waitOptions, number int, sha string) error {
+	if _, planning := c.(*plan.Recorder); planning {
+		return c.EnqueuePullRequest(number, sha)
+	}
+	before, err := c.MergeQueueStatus(number)
+	if err != nil {
+		return fmt.Errorf("failed to get merge queue status: %v", err)
+	}
+	if !before.Queued {
+		glog.Infof("adding pr %d to the merge queue", number)
+		if err := c.EnqueuePullRequest(number, sha); err != nil {
+			return fmt.Errorf("failed to enqueue PR %d: %v", number, err)
+		}
+	}
+
+	start := o.clock()
+	interval := o.minInterval
+	p := newProgress(os.Stderr)
+	defer p.done()
+	seen := before.Queued
+	for {
+		st, err := c.MergeQueueStatus(number)
+		if err != nil {
+			return fmt.Errorf("failed to get merge queue status: %v", err)
+		}
+		if st.Merged {
+			glog.Infof("Successfully submitted %d through the merge queue", number)
+			return nil
+		}
+		if st.RemovedAt.After(before.RemovedAt) {
+			return fmt.Errorf("pr %d was removed from the merge queue: %s", number, st.RemovedReason)
+		}
+		if st.Queued {
+			seen = true
+		} else if seen {
+			return fmt.Errorf("pr %d left the merge queue without being merged", number)
+		}
+		elapsed := o.clock().Sub(start)
+		if o.timeout > 0 && elapsed >= o.timeout {
+			return fmt.Errorf("pr %d %w after %v in the merge queue at position %d (%s)", number, errWaitTimeout, elapsed.Round(time.Second), st.Position, st.State)
+		}
+		if st.Queued {
+			p.update(fmt.Sprintf("pr %d: waited %v, position %d in the merge queue (%s)", number, elapsed.Round(time.Second), st.Position, st.State))
+		}
+		sleep := interval
+		if remaining := o.timeout - elapsed; o.timeout > 0 && remaining < sleep {
+			sleep = remaining
+		}
+		o.pause(sleep)
+		interval = o.next(interval)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+)
+
+// queueRepo reports statuses[n] for its n-th merge queue status request,
+// repeating the last status once they run out, and records the pull requests
+// it is asked to enqueue.
+type queueRepo struct {
+	repo.Repo
+	statuses []repo.MergeQueueStatus
+	polls    int
+	enqueued []int
+}
+
+func (f *queueRepo) MergeQueueStatus(num int) (*repo.MergeQueueStatus, error) {
+	st := f.statuses[len(f.statuses)-1]
+	if f.polls < len(f.statuses) {
+		st = f.statuses[f.polls]
+	}
+	f.polls++
+	return &st, nil
+}
+
+func (f *queueRepo) EnqueuePullRequest(num int, sha string) error {
+	f.enqueued = append(f.enqueued, num)
+	return nil
+}
+
+func TestSubmitQueued(t *testing.T) {
+	earlier := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(24 * time.Hour)
+	queued := repo.MergeQueueStatus{Queued: true, Position: 1, State: "AWAITING_CHECKS"}
+	tests := []struct {
+		name         string
+		statuses     []repo.MergeQueueStatus
+		timeout      time.Duration
+		wantEnqueued []int
+		wantErr      string
+		wantTimeout  bool
+	}{
+		{
+			name:         "merged",
+			statuses:     []repo.MergeQueueStatus{{}, queued, queued, {Merged: true}},
+			wantEnqueued: []int{1},
+		},
+		{
+			name:     "already queued",
+			statuses: []repo.MergeQueueStatus{queued, {Merged: true}},
+		},
+		{
+			name: "removed",
+			statuses: []repo.MergeQueueStatus{
+				{RemovedReason: "an earlier removal", RemovedAt: earlier},
+				{Queued: true, RemovedReason: "an earlier removal", RemovedAt: earlier},
+				{RemovedReason: "checks failed", RemovedAt: later},
+			},
+			wantEnqueued: []int{1},
+			wantErr:      "removed from the merge queue: checks failed",
+		},
+		{
+			name:         "left without merging",
+			statuses:     []repo.MergeQueueStatus{{}, queued, {}},
+			wantEnqueued: []int{1},
+			wantErr:      "left the merge queue without being merged",
+		},
+		{
+			name:         "timeout",
+			statuses:     []repo.MergeQueueStatus{{}, queued},
+			timeout:      time.Minute,
+			wantEnqueued: []int{1},
+			wantErr:      "in the merge queue at position 1 (AWAITING_CHECKS)",
+			wantTimeout:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: later}
+			f := &queueRepo{statuses: tt.statuses}
+			err := submitQueued(f, clock.options(tt.timeout), 1, "sha")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("submitQueued() error = %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("submitQueued() error = %v, want %q", err, tt.wantErr)
+			}
+			if got := errors.Is(err, errWaitTimeout); got != tt.wantTimeout {
+				t.Errorf("submitQueued() timed out = %v, want %v", got, tt.wantTimeout)
+			}
+			if !reflect.DeepEqual(f.enqueued, tt.wantEnqueued) {
+				t.Errorf("submitQueued() enqueued %v, want %v", f.enqueued, tt.wantEnqueued)
+			}
+		})
+	}
+}
+
+func TestSubmitQueuedPlanning(t *testing.T) {
+	f := &queueRepo{}
+	rec := plan.NewRecorder(f, "o", "r", "submit-pr")
+	if err := submitQueued(rec, waitOptions{}, 1, "sha"); err != nil {
+		t.Fatalf("submitQueued() error = %v", err)
+	}
+	if f.polls != 0 || f.enqueued != nil {
+		t.Errorf("submitQueued() polled %d times and enqueued %v, want neither", f.polls, f.enqueued)
+	}
+	want := []plan.Op{{Kind: plan.OpEnqueue, Number: 1, SHA: "sha"}}
+	if !reflect.DeepEqual(rec.Plan.Ops, want) {
+		t.Errorf("submitQueued() planned %+v, want %+v", rec.Plan.Ops, want)
+	}
+}
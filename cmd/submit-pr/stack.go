@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/golang/glog"
+)
+
+// submitStack submits the pull requests in the stack containing `top`, from
+// the bottom of the stack up to and including `top`. After each merge, the
+// children of the merged pull request are retargeted to `baseBranch` and given
+// `settle` for their CI to restart. The next pull request is then only
+// submitted once its CI has reported again after the retarget, since the
+// results from before it were against the old base.
+//
+// A dry run checks only the bottom pull request, since the rest cannot be
+// checked until it has been merged.
+func submitStack(c repo.Repo, cfg *config.Repo, wait waitOptions, force bool, baseBranch string, top int, method string, mo msgOptions, settle time.Duration) error {
+	prs, err := c.PullRequests()
+	if err != nil {
+		return fmt.Errorf("unable to get pull requests: %v", err)
+	}
+	s, err := stack.Find(prs, top)
+	if err != nil {
+		return fmt.Errorf("unable to find stack for PR %d: %v", top, err)
+	}
+	var todo []int
+	for _, pr := range s {
+		todo = append(todo, pr.GetNumber())
+		if pr.GetNumber() == top {
+			break
+		}
+	}
+	glog.Infof("submitting stack %v", todo)
+
+	var merged []int
+	var retargeted time.Time
+	for i, number := range todo {
+		o := wait
+		o.since = retargeted
+		if err := submitPR(c, cfg, o, force, baseBranch, number, method, mo); err != nil {
+			return fmt.Errorf("stopped at PR %d after merging %v, not submitted: %v: %w", number, merged, todo[i:], err)
+		}
+		if _, planning := c.(*plan.Recorder); planning {
+			glog.Warningf("dry run: not submitting %v because they depend on %d", todo[i+1:], number)
+			return nil
+		}
+		merged = append(merged, number)
+		pr, err := c.PullRequest(number)
+		if err != nil {
+			return fmt.Errorf("stopped after merging %v, failed to reload PR %d: %v", merged, number, err)
+		}
+		at := wait.clock()
+		children, err := stack.Retarget(c, pr)
+		if err != nil {
+			return fmt.Errorf("stopped after merging %v, failed to retarget children of PR %d: %v", merged, number, err)
+		}
+		retargeted = time.Time{}
+		if i+1 < len(todo) && contains(children, todo[i+1]) {
+			retargeted = at
+			glog.Infof("waiting %v for CI of %v to restart", settle, children)
+			wait.pause(settle)
+		}
+	}
+	glog.Infof("Successfully submitted stack %v", merged)
+	return nil
+}
+
+func contains(numbers []int, n int) bool {
+	for _, m := range numbers {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/ci"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+)
+
+// exitWaitTimeout is the exit status used when CI is still pending after the
+// wait timeout, so scripts can tell it apart from other failures.
+const exitWaitTimeout = 3
+
+// errWaitTimeout is returned (wrapped) when CI is still pending after the wait
+// timeout.
+var errWaitTimeout = errors.New("timed out waiting for CI")
+
+// exitCode returns the exit status for a submit-pr failure `err`.
+func exitCode(err error) int {
+	if errors.Is(err, errWaitTimeout) {
+		return exitWaitTimeout
+	}
+	return 1
+}
+
+// waitOptions controls how long, and how often, submit-pr polls CI.
+type waitOptions struct {
+	// timeout is the longest to wait for pending CI; 0 waits forever.
+	timeout time.Duration
+	// minInterval is the first polling interval. Each poll which is still
+	// pending increases the interval by half, up to maxInterval.
+	minInterval time.Duration
+	maxInterval time.Duration
+	// since, if set, is when the base of the pull request last changed.
+	// Contexts which have not been set since then are still pending, as
+	// they ran against the old base.
+	since time.Time
+	// now and sleep replace time.Now and time.Sleep if they are set.
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func (o waitOptions) clock() time.Time {
+	if o.now != nil {
+		return o.now()
+	}
+	return time.Now()
+}
+
+func (o waitOptions) pause(d time.Duration) {
+	if o.sleep != nil {
+		o.sleep(d)
+		return
+	}
+	time.Sleep(d)
+}
+
+func (o waitOptions) next(interval time.Duration) time.Duration {
+	interval += interval / 2
+	if interval > o.maxInterval {
+		interval = o.maxInterval
+	}
+	return interval
+}
+
+// progress writes a status line which is rewritten in place when out is a
+// terminal, and logged otherwise.
+type progress struct {
+	out      io.Writer
+	terminal bool
+	written  bool
+}
+
+func newProgress(f *os.File) *progress {
+	fi, err := f.Stat()
+	return &progress{
+		out:      f,
+		terminal: err == nil && fi.Mode()&os.ModeCharDevice != 0,
+	}
+}
+
+func (p *progress) update(line string) {
+	if !p.terminal {
+		glog.Info(line)
+		return
+	}
+	fmt.Fprintf(p.out, "\r\033[K%s", line)
+	p.written = true
+}
+
+func (p *progress) done() {
+	if p.written {
+		fmt.Fprintln(p.out)
+		p.written = false
+	}
+}
+
+// progressLine describes the pending and missing contexts of `verdict` at time
+// `now`.
+func progressLine(number int, verdict *ci.Verdict, now time.Time, elapsed time.Duration) string {
+	var pending []string
+	for _, c := range verdict.Matching(ci.Pending) {
+		if c.Since.IsZero() {
+			pending = append(pending, c.Name)
+			continue
+		}
+		pending = append(pending, fmt.Sprintf("%s (%v)", c.Name, now.Sub(c.Since).Round(time.Second)))
+	}
+	for _, name := range verdict.Missing {
+		pending = append(pending, fmt.Sprintf("%s (missing)", name))
+	}
+	return fmt.Sprintf("pr %d: waited %v for %s", number, elapsed.Round(time.Second), strings.Join(pending, ", "))
+}
+
+// waitForCI polls the CI verdict for `ref` until it is no longer pending, the
+// timeout expires, or (if force is set) immediately. If `required` is not nil,
+// only the contexts it names are waited for, and they must all report.
+func waitForCI(c repo.Repo, o waitOptions, number int, ref string, required []string, force bool) (*ci.Verdict, error) {
+	start := o.clock()
+	interval := o.minInterval
+	p := newProgress(os.Stderr)
+	defer p.done()
+	for {
+		verdict, err := ci.Get(c, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get CI verdict: %v", err)
+		}
+		if !o.since.IsZero() {
+			verdict = verdict.Since(o.since)
+		}
+		if required != nil {
+			verdict = verdict.Required(required)
+		}
+		if verdict.State != ci.Pending {
+			return verdict, nil
+		}
+		if force {
+			glog.Warningf("PR is pending, but not waiting because force was specified")
+			return verdict, nil
+		}
+		now := o.clock()
+		elapsed := now.Sub(start)
+		if o.timeout > 0 && elapsed >= o.timeout {
+			if len(verdict.Missing) > 0 {
+				return nil, fmt.Errorf("pr %d %w after %v, required checks %v never reported, still pending: %v", number, errWaitTimeout, elapsed.Round(time.Second), verdict.Missing, verdict.Matching(ci.Pending))
+			}
+			return nil, fmt.Errorf("pr %d %w after %v, still pending: %v", number, errWaitTimeout, elapsed.Round(time.Second), verdict.Matching(ci.Pending))
+		}
+		p.update(progressLine(number, verdict, now, elapsed))
+		sleep := interval
+		if remaining := o.timeout - elapsed; o.timeout > 0 && remaining < sleep {
+			sleep = remaining
+		}
+		glog.V(2).Infof("pr %d is pending: waiting %v", number, sleep)
+		o.pause(sleep)
+		interval = o.next(interval)
+	}
+}
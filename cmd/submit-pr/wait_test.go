@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/ci"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/google/go-github/v28/github"
+)
+
+// fakeClock is a clock which only moves when it sleeps, recording each sleep.
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) options(timeout time.Duration) waitOptions {
+	return waitOptions{
+		timeout:     timeout,
+		minInterval: 10 * time.Second,
+		maxInterval: 25 * time.Second,
+		now:         func() time.Time { return c.now },
+		sleep: func(d time.Duration) {
+			c.sleeps = append(c.sleeps, d)
+			c.now = c.now.Add(d)
+		},
+	}
+}
+
+// statusRepo reports the state of status context "build" for its n-th poll as
+// states[n], repeating the last state once they run out. If a state is empty,
+// no context has reported. The first `stale` polls report a status set at the
+// zero time, and the rest one set at `now`.
+type statusRepo struct {
+	repo.Repo
+	states []string
+	stale  int
+	now    func() time.Time
+	polls  int
+}
+
+func (f *statusRepo) CombinedStatus(ref string) (*github.CombinedStatus, error) {
+	s := f.states[len(f.states)-1]
+	if f.polls < len(f.states) {
+		s = f.states[f.polls]
+	}
+	var updated time.Time
+	if f.polls >= f.stale {
+		updated = f.now()
+	}
+	f.polls++
+	cs := &github.CombinedStatus{}
+	if s != "" {
+		cs.Statuses = []github.RepoStatus{{Context: github.String("build"), State: github.String(s), UpdatedAt: &updated}}
+	}
+	return cs, nil
+}
+
+func (f *statusRepo) CheckRuns(ref string) ([]*github.CheckRun, error) {
+	return nil, nil
+}
+
+func (f *statusRepo) CheckSuites(ref string) ([]*github.CheckSuite, error) {
+	return nil, nil
+}
+
+func TestNext(t *testing.T) {
+	o := waitOptions{minInterval: 10 * time.Second, maxInterval: 25 * time.Second}
+	var got []time.Duration
+	for i, interval := 0, o.minInterval; i < 5; i, interval = i+1, o.next(interval) {
+		got = append(got, interval)
+	}
+	want := []time.Duration{10 * time.Second, 15 * time.Second, 22500 * time.Millisecond, 25 * time.Second, 25 * time.Second}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("intervals = %v, want %v", got, want)
+	}
+}
+
+func TestWaitForCI(t *testing.T) {
+	tests := []struct {
+		name       string
+		states     []string
+		required   []string
+		force      bool
+		stale      int
+		since      bool
+		timeout    time.Duration
+		wantState  string
+		wantSleeps []time.Duration
+		wantErr    string
+	}{
+		{
+			name:      "success",
+			states:    []string{"success"},
+			wantState: ci.Success,
+		},
+		{
+			name:       "backs off until done",
+			states:     []string{"pending", "pending", "pending", "failure"},
+			wantState:  ci.Failure,
+			wantSleeps: []time.Duration{10 * time.Second, 15 * time.Second, 22500 * time.Millisecond},
+		},
+		{
+			name:      "force does not wait",
+			states:    []string{"pending"},
+			force:     true,
+			wantState: ci.Pending,
+		},
+		{
+			name:       "waits for CI to report after the base changed",
+			states:     []string{"success"},
+			stale:      2,
+			since:      true,
+			wantState:  ci.Success,
+			wantSleeps: []time.Duration{10 * time.Second, 15 * time.Second},
+		},
+		{
+			name:      "ignores age unless the base changed",
+			states:    []string{"success"},
+			stale:     2,
+			wantState: ci.Success,
+		},
+		{
+			name:       "timeout",
+			states:     []string{"pending"},
+			timeout:    30 * time.Second,
+			wantSleeps: []time.Duration{10 * time.Second, 15 * time.Second, 5 * time.Second},
+			wantErr:    "still pending",
+		},
+		{
+			name:       "required check never reports",
+			states:     []string{""},
+			required:   []string{"build"},
+			timeout:    20 * time.Second,
+			wantSleeps: []time.Duration{10 * time.Second, 10 * time.Second},
+			wantErr:    "required checks [build] never reported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)}
+			f := &statusRepo{states: tt.states, stale: tt.stale, now: func() time.Time { return clock.now }}
+			o := clock.options(tt.timeout)
+			if tt.since {
+				o.since = clock.now
+			}
+			v, err := waitForCI(f, o, 1, "sha", tt.required, tt.force)
+			if tt.wantErr != "" {
+				if err == nil || !errors.Is(err, errWaitTimeout) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("waitForCI() error = %v, want a timeout mentioning %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("waitForCI() error = %v", err)
+			} else if v.State != tt.wantState {
+				t.Errorf("waitForCI() state = %s, want %s", v.State, tt.wantState)
+			}
+			if !reflect.DeepEqual(clock.sleeps, tt.wantSleeps) {
+				t.Errorf("waitForCI() slept %v, want %v", clock.sleeps, tt.wantSleeps)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "timeout", err: fmt.Errorf("submitPR: pr 1 %w after 1m0s", errWaitTimeout), want: exitWaitTimeout},
+		{name: "other failure", err: errors.New("pr 1 has changes requested"), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/repo/backend"
+	"github.com/bretmckee/git-tools/pkg/urls"
+	"github.com/golang/glog"
+)
+
+// listRuns prints a line for each run in `entries`, oldest first.
+func listRuns(entries []journal.Entry) {
+	var runs []string
+	byRun := make(map[string][]journal.Entry)
+	for _, e := range entries {
+		if _, ok := byRun[e.Run]; !ok {
+			runs = append(runs, e.Run)
+		}
+		byRun[e.Run] = append(byRun[e.Run], e)
+	}
+	for _, run := range runs {
+		es := byRun[run]
+		fmt.Printf("%s\t%s\t%s\t%d changes\n", run, es[0].Command, es[0].Time.Format("2006-01-02 15:04:05"), len(es))
+		for _, e := range es {
+			before, after := e.Before, e.After
+			switch {
+			case e.Op == journal.OpEdit:
+				// Bodies are too long to list.
+				before, after = e.BeforeTitle, e.AfterTitle
+			case e.Op == journal.OpAddComment:
+				after = ""
+			case len(e.Added) > 0:
+				after = strings.Join(e.Added, ",")
+			}
+			fmt.Printf("\t%s\tPR %d\t%s\t%s -> %s\n", e.Op, e.Number, e.Ref, before, after)
+		}
+	}
+}
+
+func main() {
+	var (
+		dryRun      = flag.Bool("dry-run", false, "Dry Run mode -- print the changes instead of making them")
+		baseURL     = flag.String("url", "", "GitHub Base URL")
+		configPath  = flag.String("config", config.DefaultPath(), "Path of the configuration file selecting the backend of the repo")
+		journalDir  = flag.String("journal", journal.Dir(), "Directory of the journals")
+		list        = flag.Bool("list", false, "List the journaled runs instead of undoing one")
+		login       = flag.String("login", "", "Login of the user to undo for.")
+		run         = flag.String("run", "", "ID of the run to undo, as shown by -list")
+		sourceOwner = flag.String("source-owner", "", "Name of the owner (user or org) of the repo to create the commit in.")
+		sourceRepo  = flag.String("source-repo", "", "Name of repo to create the commit in.")
+		token       = flag.String("token", "", "github auth token to use (also checks environment GITHUB_TOKEN")
+		uploadURL   = flag.String("upload", "", "GitHub Upload URL")
+	)
+	flag.Parse()
+	if *sourceOwner == "" || *sourceRepo == "" {
+		glog.Exitf("A non-empty value must be specified for the flags `-source-owner (=%q)` and `-source-repo (=%q)`", *sourceOwner, *sourceRepo)
+	}
+	if *list == (*run != "") {
+		glog.Exit("Exactly one of `-list` or `-run` must be specified")
+	}
+
+	path := journal.Path(*journalDir, *sourceOwner, *sourceRepo)
+	entries, err := journal.Read(path)
+	if err != nil {
+		glog.Exitf("failed to read journal: %v", err)
+	}
+	if *list {
+		listRuns(entries)
+		return
+	}
+	undo := journal.ForRun(entries, *run)
+	if len(undo) == 0 {
+		glog.Exitf("run %s made no changes to %s/%s", *run, *sourceOwner, *sourceRepo)
+	}
+
+	if *token == "" {
+		*token = os.Getenv("GITHUB_TOKEN")
+	}
+	if *token == "" {
+		glog.Exit("Unauthorized: No token present")
+	}
+	if *login == "" {
+		glog.Exitf("A non-empty value must be specified for the flag `-login (=%q)`", *login)
+	}
+
+	b, u, err := urls.Get(*baseURL, *uploadURL)
+	if err != nil {
+		glog.Exitf("failed to get URLs: %v", err)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		glog.Exitf("failed to load config: %v", err)
+	}
+
+	c, err := backend.Create(cfg, b, u, *sourceOwner, *sourceRepo, *login, *token)
+	if err != nil {
+		glog.Exitf("failed to create client: %v", err)
+	}
+
+	// The undo is itself journaled, so it can be undone too.
+	var r repo.Repo
+	var rec *plan.Recorder
+	if *dryRun {
+		rec = plan.NewRecorder(c, *sourceOwner, *sourceRepo, "undo-run")
+		r = rec
+	} else {
+		r = journal.Wrap(c, journal.Open(path, "undo-run"))
+	}
+	if err := journal.Undo(r, undo); err != nil {
+		glog.Exitf("undo failed: %v", err)
+	}
+	if rec != nil {
+		if err := rec.Plan.Print(os.Stdout); err != nil {
+			glog.Exitf("failed to print plan: %v", err)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/ci"
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/message"
+	"github.com/bretmckee/git-tools/pkg/plan"
+	"github.com/bretmckee/git-tools/pkg/repo/repodata"
+	"github.com/bretmckee/git-tools/pkg/review"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/bretmckee/git-tools/pkg/state"
+	"github.com/bretmckee/git-tools/pkg/urls"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// defaultMethod is the merge method used when a repository does not configure
+// one.
+const defaultMethod = "squash"
+
+// watcher moves the stacks of the watched repositories along: it retargets the
+// children of merged pull requests, marks those opened by login ready for
+// review, and submits the approved stack bottoms opened by login whose checks
+// pass if the repository allows it.
+type watcher struct {
+	cfg *config.Config
+	st  *state.State
+	// dryRun makes each pass plan its changes and print the plan instead
+	// of making them.
+	dryRun bool
+	// journalDir is the directory of the journals, or empty to not keep
+	// them.
+	journalDir string
+	baseURL    string
+	upload     string
+	login      string
+	token      string
+	repos      map[string]*repodata.RepoData
+}
+
+// key returns the state key for `action` on pull request `number` of `name`.
+func key(name string, number int, action string) string {
+	return fmt.Sprintf("%s#%d:%s", name, number, action)
+}
+
+// record records `k` in the state unless this is a dry run.
+func (w *watcher) record(k string) error {
+	if w.dryRun {
+		return nil
+	}
+	return w.st.Record(k)
+}
+
+// load returns the freshly loaded data for repository `name` (owner/repo).
+func (w *watcher) load(name string) (*repodata.RepoData, error) {
+	if r, ok := w.repos[name]; ok {
+		if err := r.LoadData(); err != nil {
+			return nil, fmt.Errorf("failed to reload data: %v", err)
+		}
+		return r, nil
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("repository %q is not of the form owner/repo", name)
+	}
+	r, err := repodata.Create(w.cfg, w.baseURL, w.upload, parts[0], parts[1], "", "", w.login, w.token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repodata: %v", err)
+	}
+	w.repos[name] = r
+	return r, nil
+}
+
+// openPRs returns the open pull requests of `r`.
+func openPRs(r *repodata.RepoData) []*github.PullRequest {
+	var prs []*github.PullRequest
+	for _, pr := range r.PrByNumber {
+		prs = append(prs, pr)
+	}
+	return prs
+}
+
+// promotable returns `prs` with every draft which the watcher marked ready
+// before (and which someone then converted back to a draft) shown as ready for
+// review, so that stack.Promote leaves it alone. Whether the other drafts are
+// the bottom of their stack is decided by stack.Promote from `prs` themselves,
+// whoever retargeted them.
+func (w *watcher) promotable(name string, prs []*github.PullRequest) []*github.PullRequest {
+	res := make([]*github.PullRequest, len(prs))
+	for i, pr := range prs {
+		res[i] = pr
+		if pr.GetDraft() && w.st.Did(key(name, pr.GetNumber(), "ready")) {
+			held := *pr
+			held.Draft = github.Bool(false)
+			res[i] = &held
+		}
+	}
+	return res
+}
+
+// pass makes one pass over repository `name`, journaled as a run of its own so
+// that undo-run can undo it alone.
+func (w *watcher) pass(name string) error {
+	r, err := w.load(name)
+	if err != nil {
+		return err
+	}
+	parts := strings.SplitN(name, "/", 2)
+	if w.journalDir != "" && !w.dryRun {
+		base := r.Repo
+		r.Repo = journal.Wrap(base, journal.Open(journal.Path(w.journalDir, parts[0], parts[1]), "watch-stacks"))
+		defer func() { r.Repo = base }()
+	}
+	if w.dryRun {
+		rec := plan.NewRecorder(r.Repo, parts[0], parts[1], "watch-stacks")
+		r.Repo = rec
+		defer func() {
+			r.Repo = rec.Repo
+			if len(rec.Plan.Ops) == 0 {
+				return
+			}
+			if err := rec.Plan.Print(os.Stdout); err != nil {
+				glog.Errorf("failed to print plan for %s: %v", name, err)
+			}
+		}()
+	}
+
+	retargeted, err := stack.Sweep(r)
+	if err != nil {
+		return fmt.Errorf("sweep failed: %v", err)
+	}
+	if len(retargeted) > 0 {
+		if err := r.LoadData(); err != nil {
+			return fmt.Errorf("failed to reload data: %v", err)
+		}
+	}
+
+	promoted, err := stack.Promote(r, w.promotable(name, openPRs(r)), w.login, false)
+	if err != nil {
+		return err
+	}
+	for _, n := range promoted {
+		if err := w.record(key(name, n, "ready")); err != nil {
+			return err
+		}
+	}
+
+	rcfg := w.cfg.Repo(parts[0], parts[1])
+	if !rcfg.Submits() {
+		return nil
+	}
+	for _, pr := range stack.Bottoms(openPRs(r)) {
+		if pr.GetUser().GetLogin() != w.login {
+			continue
+		}
+		if err := w.submit(r, rcfg, name, pr); err != nil {
+			glog.Warningf("failed to submit %s PR %d: %v", name, pr.GetNumber(), err)
+		}
+	}
+	return nil
+}
+
+// submit merges (or enqueues) stack bottom `pr` if it is ready for review,
+// approved and its required checks pass. Each head is only submitted once, so
+// a failed merge is not retried until the pull request changes.
+func (w *watcher) submit(r *repodata.RepoData, rcfg *config.Repo, name string, pr *github.PullRequest) error {
+	n := pr.GetNumber()
+	sha := pr.GetHead().GetSHA()
+	k := key(name, n, "submit:"+sha)
+	if pr.GetDraft() || w.st.Did(k) {
+		return nil
+	}
+	base := pr.GetBase().GetRef()
+	p, err := r.BranchProtection(base)
+	if err != nil {
+		return fmt.Errorf("failed to get protection for %q: %v", base, err)
+	}
+	reviews, err := r.Reviews(n)
+	if err != nil {
+		return fmt.Errorf("failed to get reviews: %v", err)
+	}
+	res := review.Decide(reviews, review.Required(rcfg.RequiredApprovals, p), pr.GetUser().GetLogin())
+	if res.Decision != review.Approved {
+		glog.V(1).Infof("%s PR %d is not submittable: review decision %s", name, n, res.Decision)
+		return nil
+	}
+	v, err := ci.Get(r, sha)
+	if err != nil {
+		return err
+	}
+	if v = v.Required(ci.RequiredContexts(p)); v.State != ci.Success {
+		glog.V(1).Infof("%s PR %d is not submittable: checks are %s", name, n, v.State)
+		return nil
+	}
+	// Whatever happens, do not try this head again.
+	if err := w.record(k); err != nil {
+		return err
+	}
+	queued, err := r.MergeQueueEnabled(base)
+	if err != nil {
+		return err
+	}
+	if queued {
+		glog.Infof("adding %s PR %d to the merge queue", name, n)
+		return r.EnqueuePullRequest(n, sha)
+	}
+	msg, err := message.ForPullRequest(r, rcfg.MessageTemplate, pr, res.Approvers, false)
+	if err != nil {
+		return err
+	}
+	method := rcfg.MergeMethod
+	if method == "" {
+		method = defaultMethod
+	}
+	glog.Infof("submitting %s PR %d", name, n)
+	if _, err := r.MergePullRequest(n, sha, method, msg); err != nil {
+		return err
+	}
+	return nil
+}
+
+func main() {
+	var (
+		dryRun     = flag.Bool("dry-run", false, "Dry Run mode -- print the changes instead of making them")
+		baseURL    = flag.String("url", "", "GitHub Base URL")
+		configPath = flag.String("config", config.DefaultPath(), "Path of the configuration file listing the repos to watch")
+		interval   = flag.Duration("interval", 5*time.Minute, "Time between passes over the watched repos")
+		journalDir = flag.String("journal", journal.Dir(), "Directory of the journals recording changes for undo-run (empty to disable)")
+		login      = flag.String("login", "", "Login of the user to watch for.")
+		once       = flag.Bool("once", false, "Make a single pass over the watched repos and exit")
+		statePath  = flag.String("state", state.DefaultPath("watch-stacks"), "Path of the file recording the actions already taken")
+		token      = flag.String("token", "", "github auth token to use (also checks environment GITHUB_TOKEN")
+		uploadURL  = flag.String("upload", "", "GitHub Upload URL")
+	)
+	flag.Parse()
+	if *token == "" {
+		*token = os.Getenv("GITHUB_TOKEN")
+	}
+	if *token == "" {
+		glog.Exit("Unauthorized: No token present")
+	}
+	if *login == "" {
+		glog.Exitf("A non-empty value must be specified for the flag `-login (=%q)`", *login)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		glog.Exitf("failed to load config: %v", err)
+	}
+	if len(cfg.Watch) == 0 {
+		glog.Exitf("no repos to watch are listed in %s", *configPath)
+	}
+	st, err := state.Load(*statePath)
+	if err != nil {
+		glog.Exitf("failed to load state: %v", err)
+	}
+
+	b, u, err := urls.Get(*baseURL, *uploadURL)
+	if err != nil {
+		glog.Exitf("failed to get URLs: %v", err)
+	}
+
+	w := &watcher{
+		cfg:        cfg,
+		st:         st,
+		dryRun:     *dryRun,
+		journalDir: *journalDir,
+		baseURL:    b,
+		upload:     u,
+		login:      *login,
+		token:      *token,
+		repos:      make(map[string]*repodata.RepoData),
+	}
+	for {
+		for _, name := range cfg.Watch {
+			glog.V(1).Infof("watching %s", name)
+			if err := w.pass(name); err != nil {
+				glog.Errorf("pass over %s failed: %v", name, err)
+			}
+		}
+		if *once {
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
@@ -0,0 +1,221 @@
+// Package ci combines legacy commit statuses and check runs into a single
+// verdict for a commit.
+package ci
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/google/go-github/v28/github"
+)
+
+// States of a context and of a verdict.
+const (
+	Success = "success"
+	Pending = "pending"
+	Failure = "failure"
+)
+
+// Kinds of context.
+const (
+	KindStatus = "status"
+	KindCheck  = "check"
+)
+
+// actionsApp is the name of the GitHub Actions app. Its suites are created
+// before their runs, so an incomplete suite without runs means that work is
+// about to start. Other apps often leave suites queued forever, so those are
+// ignored.
+const actionsApp = "GitHub Actions"
+
+// Context is the state of a single status context or check.
+type Context struct {
+	Name  string
+	Kind  string
+	State string
+	URL   string
+	// Since is when the status was last set or the check started, if known.
+	Since time.Time
+}
+
+func (c Context) String() string {
+	return fmt.Sprintf("%s %s (%s)", c.Kind, c.Name, c.State)
+}
+
+// Verdict is the combined CI state of a commit.
+type Verdict struct {
+	// State is Failure if any context failed, otherwise Pending if any
+	// context is still running or missing, otherwise Success.
+	State    string
+	Contexts []Context
+	// Missing are the required contexts which have not reported at all.
+	Missing []string
+	// Optional are the contexts which were left out of State because they
+	// are not required.
+	Optional []Context
+}
+
+// statusState maps a commit status state to a context state.
+func statusState(s string) string {
+	switch s {
+	case "success":
+		return Success
+	case "pending":
+		return Pending
+	default:
+		return Failure
+	}
+}
+
+// checkState maps a check run (or suite) status and conclusion to a context
+// state.
+func checkState(status, conclusion string) string {
+	if status != "completed" {
+		return Pending
+	}
+	switch conclusion {
+	case "success", "neutral", "skipped":
+		return Success
+	default:
+		return Failure
+	}
+}
+
+// Combine computes the verdict from the combined status and the check runs and
+// suites of a commit.
+func Combine(status *github.CombinedStatus, runs []*github.CheckRun, suites []*github.CheckSuite) *Verdict {
+	v := &Verdict{}
+	for _, s := range status.Statuses {
+		v.Contexts = append(v.Contexts, Context{
+			Name:  s.GetContext(),
+			Kind:  KindStatus,
+			State: statusState(s.GetState()),
+			URL:   s.GetTargetURL(),
+			Since: s.GetUpdatedAt(),
+		})
+	}
+	hasRuns := make(map[int64]bool)
+	for _, r := range runs {
+		hasRuns[r.GetCheckSuite().GetID()] = true
+		v.Contexts = append(v.Contexts, Context{
+			Name:  r.GetName(),
+			Kind:  KindCheck,
+			State: checkState(r.GetStatus(), r.GetConclusion()),
+			URL:   r.GetHTMLURL(),
+			Since: r.GetStartedAt().Time,
+		})
+	}
+	for _, s := range suites {
+		if hasRuns[s.GetID()] || s.GetApp().GetName() != actionsApp || s.GetStatus() == "completed" {
+			continue
+		}
+		v.Contexts = append(v.Contexts, Context{
+			Name:  fmt.Sprintf("%s suite %d", s.GetApp().GetName(), s.GetID()),
+			Kind:  KindCheck,
+			State: Pending,
+		})
+	}
+	v.State = state(v.Contexts)
+	return v
+}
+
+// Get fetches the statuses, check runs and check suites of commit `ref` and
+// combines them.
+func Get(r repo.Repo, ref string) (*Verdict, error) {
+	status, err := r.CombinedStatus(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get combined status: %v", err)
+	}
+	runs, err := r.CheckRuns(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get check runs: %v", err)
+	}
+	suites, err := r.CheckSuites(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get check suites: %v", err)
+	}
+	return Combine(status, runs, suites), nil
+}
+
+func state(contexts []Context) string {
+	res := Success
+	for _, c := range contexts {
+		switch c.State {
+		case Failure:
+			return Failure
+		case Pending:
+			res = Pending
+		}
+	}
+	return res
+}
+
+// Required returns the verdict considering only the contexts named in
+// `required`. The other contexts are moved to Optional, and required contexts
+// which have not reported are listed in Missing and keep the verdict pending.
+func (v *Verdict) Required(required []string) *Verdict {
+	want := make(map[string]bool)
+	for _, name := range required {
+		want[name] = true
+	}
+	res := &Verdict{}
+	seen := make(map[string]bool)
+	for _, c := range v.Contexts {
+		if !want[c.Name] {
+			res.Optional = append(res.Optional, c)
+			continue
+		}
+		seen[c.Name] = true
+		res.Contexts = append(res.Contexts, c)
+	}
+	for _, name := range required {
+		if !seen[name] {
+			res.Missing = append(res.Missing, name)
+		}
+	}
+	res.State = state(res.Contexts)
+	if res.State == Success && len(res.Missing) > 0 {
+		res.State = Pending
+	}
+	return res
+}
+
+// Since returns the verdict treating every context which was last set, or
+// started, before `t` as pending, so that results from before a change (for
+// example of the base branch) are not mistaken for results after it.
+func (v *Verdict) Since(t time.Time) *Verdict {
+	res := &Verdict{Missing: v.Missing, Optional: v.Optional}
+	for _, c := range v.Contexts {
+		if c.Since.Before(t) {
+			c.State = Pending
+		}
+		res.Contexts = append(res.Contexts, c)
+	}
+	res.State = state(res.Contexts)
+	if res.State == Success && len(res.Missing) > 0 {
+		res.State = Pending
+	}
+	return res
+}
+
+// Matching returns the contexts in state `state`.
+func (v *Verdict) Matching(state string) []Context {
+	var res []Context
+	for _, c := range v.Contexts {
+		if c.State == state {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// RequiredContexts returns the status checks which branch protection `p`
+// requires, or nil if it does not require any (or `p` is nil), in which case
+// every check is treated as required.
+func RequiredContexts(p *github.Protection) []string {
+	if rs := p.GetRequiredStatusChecks(); rs != nil && len(rs.Contexts) > 0 {
+		return rs.Contexts
+	}
+	return nil
+}
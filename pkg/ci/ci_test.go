@@ -0,0 +1,205 @@
+package ci
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func newStatus(context, state string) github.RepoStatus {
+	return github.RepoStatus{Context: github.String(context), State: github.String(state)}
+}
+
+func newRun(name, status, conclusion string, suite int64) *github.CheckRun {
+	r := &github.CheckRun{
+		Name:       github.String(name),
+		Status:     github.String(status),
+		CheckSuite: &github.CheckSuite{ID: github.Int64(suite)},
+	}
+	if conclusion != "" {
+		r.Conclusion = github.String(conclusion)
+	}
+	return r
+}
+
+func newSuite(id int64, app, status string) *github.CheckSuite {
+	return &github.CheckSuite{
+		ID:     github.Int64(id),
+		App:    &github.App{Name: github.String(app)},
+		Status: github.String(status),
+	}
+}
+
+func TestCombine(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *github.CombinedStatus
+		runs   []*github.CheckRun
+		suites []*github.CheckSuite
+		want   string
+	}{
+		{
+			name:   "nothing reported",
+			status: &github.CombinedStatus{State: github.String("pending")},
+			want:   Success,
+		},
+		{
+			name:   "statuses only",
+			status: &github.CombinedStatus{Statuses: []github.RepoStatus{newStatus("ci", "success")}},
+			want:   Success,
+		},
+		{
+			name:   "status error",
+			status: &github.CombinedStatus{Statuses: []github.RepoStatus{newStatus("ci", "error")}},
+			want:   Failure,
+		},
+		{
+			name:   "check running",
+			status: &github.CombinedStatus{Statuses: []github.RepoStatus{newStatus("ci", "success")}},
+			runs:   []*github.CheckRun{newRun("build", "in_progress", "", 1)},
+			want:   Pending,
+		},
+		{
+			name:   "check failed while status pending",
+			status: &github.CombinedStatus{Statuses: []github.RepoStatus{newStatus("ci", "pending")}},
+			runs:   []*github.CheckRun{newRun("build", "completed", "timed_out", 1)},
+			want:   Failure,
+		},
+		{
+			name:   "neutral and skipped checks",
+			status: &github.CombinedStatus{},
+			runs: []*github.CheckRun{
+				newRun("lint", "completed", "neutral", 1),
+				newRun("deploy", "completed", "skipped", 1),
+			},
+			want: Success,
+		},
+		{
+			name:   "queued actions suite without runs",
+			status: &github.CombinedStatus{},
+			suites: []*github.CheckSuite{newSuite(1, actionsApp, "queued")},
+			want:   Pending,
+		},
+		{
+			name:   "queued suite from another app",
+			status: &github.CombinedStatus{},
+			suites: []*github.CheckSuite{newSuite(1, "Some App", "queued")},
+			want:   Success,
+		},
+		{
+			name:   "actions suite with completed runs",
+			status: &github.CombinedStatus{},
+			runs:   []*github.CheckRun{newRun("build", "completed", "success", 1)},
+			suites: []*github.CheckSuite{newSuite(1, actionsApp, "in_progress")},
+			want:   Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Combine(tt.status, tt.runs, tt.suites); got.State != tt.want {
+				t.Errorf("Combine() State = %s, want %s (contexts %v)", got.State, tt.want, got.Contexts)
+			}
+		})
+	}
+}
+
+func TestRequired(t *testing.T) {
+	v := Combine(&github.CombinedStatus{Statuses: []github.RepoStatus{
+		newStatus("required-status", "success"),
+		newStatus("optional-status", "failure"),
+	}}, []*github.CheckRun{
+		newRun("required-check", "completed", "success", 1),
+		newRun("optional-check", "in_progress", "", 1),
+	}, nil)
+
+	tests := []struct {
+		name         string
+		required     []string
+		want         string
+		wantMissing  int
+		wantOptional int
+	}{
+		{
+			name:         "required contexts pass",
+			required:     []string{"required-status", "required-check"},
+			want:         Success,
+			wantOptional: 2,
+		},
+		{
+			name:         "required context missing",
+			required:     []string{"required-check", "never-reported"},
+			want:         Pending,
+			wantMissing:  1,
+			wantOptional: 3,
+		},
+		{
+			name:         "required context failed",
+			required:     []string{"optional-status", "never-reported"},
+			want:         Failure,
+			wantMissing:  1,
+			wantOptional: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := v.Required(tt.required)
+			if got.State != tt.want {
+				t.Errorf("Required() State = %s, want %s", got.State, tt.want)
+			}
+			if len(got.Missing) != tt.wantMissing {
+				t.Errorf("Required() Missing = %v, want %d", got.Missing, tt.wantMissing)
+			}
+			if len(got.Optional) != tt.wantOptional {
+				t.Errorf("Required() Optional = %v, want %d", got.Optional, tt.wantOptional)
+			}
+		})
+	}
+}
+
+func TestSince(t *testing.T) {
+	retarget := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	at := func(s github.RepoStatus, t time.Time) github.RepoStatus {
+		s.UpdatedAt = &t
+		return s
+	}
+	tests := []struct {
+		name     string
+		statuses []github.RepoStatus
+		want     string
+	}{
+		{
+			name: "reran after the change",
+			statuses: []github.RepoStatus{
+				at(newStatus("build", "success"), retarget.Add(time.Minute)),
+			},
+			want: Success,
+		},
+		{
+			name: "only ran before the change",
+			statuses: []github.RepoStatus{
+				at(newStatus("build", "success"), retarget.Add(-time.Minute)),
+				at(newStatus("lint", "success"), retarget.Add(time.Minute)),
+			},
+			want: Pending,
+		},
+		{
+			name: "failed after the change",
+			statuses: []github.RepoStatus{
+				at(newStatus("build", "success"), retarget.Add(-time.Minute)),
+				at(newStatus("lint", "failure"), retarget.Add(time.Minute)),
+			},
+			want: Failure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Combine(&github.CombinedStatus{Statuses: tt.statuses}, nil, nil)
+			if got := v.Since(retarget).State; got != tt.want {
+				t.Errorf("Since() State = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
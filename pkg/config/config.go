@@ -0,0 +1,121 @@
+// Package config loads the optional git-tools configuration file.
+//
+// The file is JSON. Settings in "defaults" apply to every repository, and
+// entries in "repos", keyed by "owner/repo", override them field by field:
+//
+//	{
+//	  "defaults": {"required_approvals": 1},
+//	  "repos": {
+//	    "bretmckee/git-tools": {"required_approvals": 2},
+//	    "team/service": {"backend": "gitlab", "url": "https://gitlab.example.com/api/v4"}
+//	  },
+//	  "watch": ["bretmckee/git-tools"]
+//	}
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Repo holds the settings for a single repository.
+type Repo struct {
+	// RequiredApprovals is the minimum number of approving reviews submit-pr
+	// requires. Branch protection may require more.
+	RequiredApprovals int `json:"required_approvals,omitempty"`
+
+	// MessageTemplate is the text/template submit-pr uses for the merge
+	// commit message. See package message for the data it can use.
+	MessageTemplate string `json:"message_template,omitempty"`
+
+	// AutoSubmit lets watch-stacks submit the bottom pull request of a stack
+	// once it is approved and its checks pass. It is a pointer so that a
+	// repository can turn off a default of true; use Submits to read it.
+	AutoSubmit *bool `json:"auto_submit,omitempty"`
+
+	// MergeMethod is the merge method (merge, rebase or squash) watch-stacks
+	// submits with. The default is squash.
+	MergeMethod string `json:"merge_method,omitempty"`
+
+	// Backend is the service hosting the repository: "github" (the
+	// default) or "gitlab".
+	Backend string `json:"backend,omitempty"`
+
+	// URL is the API URL of the backend. It overrides the -url flag, and for
+	// GitLab defaults to https://gitlab.com/api/v4.
+	URL string `json:"url,omitempty"`
+}
+
+// Config is the contents of a configuration file.
+type Config struct {
+	Defaults Repo             `json:"defaults"`
+	Repos    map[string]*Repo `json:"repos,omitempty"`
+	// Watch lists the repositories, as "owner/repo", watch-stacks watches.
+	Watch []string `json:"watch,omitempty"`
+}
+
+// DefaultPath returns the path of the configuration file used when none is
+// specified: $XDG_CONFIG_HOME/git-tools/config.json, or
+// ~/.config/git-tools/config.json.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(dir, "git-tools", "config.json")
+}
+
+// Load reads the configuration file at `path`. A missing file is not an error;
+// it results in an empty configuration.
+func Load(path string) (*Config, error) {
+	c := &Config{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %q: %v", path, err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse config %q: %v", path, err)
+	}
+	return c, nil
+}
+
+// Submits reports whether AutoSubmit is set to true.
+func (r *Repo) Submits() bool {
+	return r.AutoSubmit != nil && *r.AutoSubmit
+}
+
+// Repo returns the settings for repository `owner`/`name`: the defaults,
+// overridden by any non-zero fields set for the repository, and by
+// AutoSubmit whenever it is set.
+func (c *Config) Repo(owner, name string) *Repo {
+	r := c.Defaults
+	o, ok := c.Repos[owner+"/"+name]
+	if !ok {
+		return &r
+	}
+	if o.RequiredApprovals != 0 {
+		r.RequiredApprovals = o.RequiredApprovals
+	}
+	if o.MessageTemplate != "" {
+		r.MessageTemplate = o.MessageTemplate
+	}
+	if o.AutoSubmit != nil {
+		r.AutoSubmit = o.AutoSubmit
+	}
+	if o.MergeMethod != "" {
+		r.MergeMethod = o.MergeMethod
+	}
+	if o.Backend != "" {
+		r.Backend = o.Backend
+	}
+	if o.URL != "" {
+		r.URL = o.URL
+	}
+	return &r
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRepo(t *testing.T) {
+	yes, no := true, false
+	c := &Config{
+		Defaults: Repo{RequiredApprovals: 1, AutoSubmit: &yes, MergeMethod: "squash"},
+		Repos: map[string]*Repo{
+			"o/approvals": {RequiredApprovals: 2},
+			"o/manual":    {AutoSubmit: &no},
+			"o/gitlab":    {Backend: "gitlab", URL: "https://gitlab.example.com/api/v4"},
+		},
+	}
+	tests := []struct {
+		name       string
+		repo       string
+		want       Repo
+		wantSubmit bool
+	}{
+		{
+			name:       "defaults",
+			repo:       "other",
+			want:       Repo{RequiredApprovals: 1, AutoSubmit: &yes, MergeMethod: "squash"},
+			wantSubmit: true,
+		},
+		{
+			name:       "override",
+			repo:       "approvals",
+			want:       Repo{RequiredApprovals: 2, AutoSubmit: &yes, MergeMethod: "squash"},
+			wantSubmit: true,
+		},
+		{
+			name: "auto submit turned off",
+			repo: "manual",
+			want: Repo{RequiredApprovals: 1, AutoSubmit: &no, MergeMethod: "squash"},
+		},
+		{
+			name:       "backend",
+			repo:       "gitlab",
+			want:       Repo{RequiredApprovals: 1, AutoSubmit: &yes, MergeMethod: "squash", Backend: "gitlab", URL: "https://gitlab.example.com/api/v4"},
+			wantSubmit: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.Repo("o", tt.repo)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Repo() = %+v, want %+v", *got, tt.want)
+			}
+			if got.Submits() != tt.wantSubmit {
+				t.Errorf("Repo().Submits() = %v, want %v", got.Submits(), tt.wantSubmit)
+			}
+		})
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c.Repo("o", "r").Submits() {
+		t.Errorf("Load() of a missing file submits automatically")
+	}
+}
@@ -0,0 +1,131 @@
+// Package git runs git commands in a local clone.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// ErrConflict is returned (wrapped) when a rebase stops because of conflicts.
+var ErrConflict = errors.New("rebase conflict")
+
+// Git runs git commands in a directory.
+type Git struct {
+	Dir string
+}
+
+// New returns a Git which runs commands in `dir`.
+func New(dir string) *Git {
+	return &Git{Dir: dir}
+}
+
+// Run runs git with `args` and returns its trimmed standard output.
+func (g *Git) Run(args ...string) (string, error) {
+	glog.V(2).Infof("running git %s in %s", strings.Join(args, " "), g.Dir)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.Dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// RevParse returns the SHA of `rev`.
+func (g *Git) RevParse(rev string) (string, error) {
+	return g.Run("rev-parse", "--verify", rev+"^{commit}")
+}
+
+// RebaseOnto rebases `branch` on `remote` onto `newBase`, dropping the commits
+// reachable from `upstream`. The rebase happens in a temporary worktree, so the
+// caller's checkout is not touched, and nothing is pushed: the caller pushes
+// the new SHA with Push. If the rebase stops because of conflicts it is aborted
+// and an error wrapping ErrConflict is returned. RebaseOnto returns the old and
+// new SHAs of the branch.
+func (g *Git) RebaseOnto(remote, branch, newBase, upstream string) (string, string, error) {
+	if _, err := g.Run("fetch", remote, newBase, branch); err != nil {
+		return "", "", err
+	}
+	oldSHA, err := g.RevParse("refs/remotes/" + remote + "/" + branch)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := g.RevParse(upstream); err != nil {
+		return "", "", fmt.Errorf("upstream %s of %s is not available locally: %v", upstream, branch, err)
+	}
+
+	dir, err := ioutil.TempDir("", "git-tools-rebase-")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create worktree directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := g.Run("worktree", "add", "--detach", dir, oldSHA); err != nil {
+		return "", "", err
+	}
+	defer func() {
+		if _, err := g.Run("worktree", "remove", "--force", dir); err != nil {
+			glog.Warningf("failed to remove worktree %s: %v", dir, err)
+		}
+	}()
+
+	wt := New(dir)
+	if _, err := wt.Run("rebase", "--onto", "refs/remotes/"+remote+"/"+newBase, upstream); err != nil {
+		if _, abortErr := wt.Run("rebase", "--abort"); abortErr != nil {
+			glog.Warningf("failed to abort rebase of %s: %v", branch, abortErr)
+		}
+		return "", "", fmt.Errorf("%w rebasing %s onto %s: %v", ErrConflict, branch, newBase, err)
+	}
+	newSHA, err := wt.RevParse("HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	if newSHA == oldSHA {
+		glog.V(1).Infof("%s is already based on %s", branch, newBase)
+	}
+	return oldSHA, newSHA, nil
+}
+
+// Push sets `branch` on `remote` to `newSHA`, provided it is still at
+// `oldSHA`.
+func (g *Git) Push(remote, branch, oldSHA, newSHA string) error {
+	lease := fmt.Sprintf("--force-with-lease=refs/heads/%s:%s", branch, oldSHA)
+	_, err := g.Run("push", lease, remote, newSHA+":refs/heads/"+branch)
+	return err
+}
+
+// RemoteSHA returns the SHA of `branch` on `remote`, or "" if it does not
+// exist there.
+func (g *Git) RemoteSHA(remote, branch string) (string, error) {
+	out, err := g.Run("ls-remote", remote, "refs/heads/"+branch)
+	if err != nil {
+		return "", err
+	}
+	return strings.SplitN(out, "\t", 2)[0], nil
+}
+
+// LocalBranches returns the names of the local branches.
+func (g *Git) LocalBranches() ([]string, error) {
+	out, err := g.Run("for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// DeleteBranch deletes local branch `name`, whatever its merge status.
+func (g *Git) DeleteBranch(name string) error {
+	_, err := g.Run("branch", "-D", name)
+	return err
+}
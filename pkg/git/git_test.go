@@ -0,0 +1,121 @@
+package git
+
+import (
+	"errors"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setup creates a bare remote and a clone of it with a master branch, a parent
+// branch with two commits, and a child branch on top of the parent. It then
+// squash merges the parent into master, changing `file` to `masterContent` in
+// the same commit, and pushes everything.
+func setup(t *testing.T, masterContent string) (*Git, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	clone := filepath.Join(dir, "clone")
+	run := func(g *Git, args ...string) string {
+		t.Helper()
+		out, err := g.Run(args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := ioutil.WriteFile(filepath.Join(clone, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := func(g *Git, name, content string) {
+		t.Helper()
+		write(name, content)
+		run(g, "add", name)
+		run(g, "commit", "-q", "-m", name+": "+content)
+	}
+
+	run(New(dir), "init", "-q", "--bare", "-b", "master", remote)
+	run(New(dir), "clone", "-q", remote, clone)
+	g := New(clone)
+	run(g, "checkout", "-q", "-b", "master")
+	commit(g, "file", "base\n")
+	run(g, "checkout", "-q", "-b", "parent")
+	commit(g, "parent", "one\n")
+	commit(g, "parent", "two\n")
+	upstream := run(g, "rev-parse", "HEAD")
+	run(g, "checkout", "-q", "-b", "child")
+	commit(g, "child", "child\n")
+	commit(g, "file", "child\n")
+	run(g, "checkout", "-q", "master")
+	write("parent", "two\n")
+	write("file", masterContent)
+	run(g, "add", "parent", "file")
+	run(g, "commit", "-q", "-m", "squashed parent")
+	run(g, "push", "-q", "origin", "master", "parent", "child")
+	return g, upstream
+}
+
+func TestRebaseOnto(t *testing.T) {
+	g, upstream := setup(t, "base\n")
+	old, sha, err := g.RebaseOnto("origin", "child", "master", upstream)
+	if err != nil {
+		t.Fatalf("RebaseOnto() error = %v", err)
+	}
+	if err := g.Push("origin", "child", old, sha); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	pushed, err := g.Run("ls-remote", "origin", "refs/heads/child")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sha + "\trefs/heads/child"; pushed != want {
+		t.Errorf("remote child = %q, want %q", pushed, want)
+	}
+	count, err := g.Run("rev-list", "--count", "refs/remotes/origin/master.."+sha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != "2" {
+		t.Errorf("rebased child has %s commits on top of master, want 2", count)
+	}
+}
+
+func TestRebaseOntoConflict(t *testing.T) {
+	g, upstream := setup(t, "master\n")
+	before, err := g.Run("ls-remote", "origin", "refs/heads/child")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := g.RebaseOnto("origin", "child", "master", upstream); !errors.Is(err, ErrConflict) {
+		t.Fatalf("RebaseOnto() error = %v, want %v", err, ErrConflict)
+	}
+	after, err := g.Run("ls-remote", "origin", "refs/heads/child")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != before {
+		t.Errorf("remote child changed from %q to %q after a conflict", before, after)
+	}
+	wts, err := g.Run("worktree", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(strings.Split(wts, "\n")); n != 1 {
+		t.Errorf("%d worktrees after a conflict, want 1:\n%s", n, wts)
+	}
+}
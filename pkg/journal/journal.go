@@ -0,0 +1,164 @@
+// Package journal records the changes commands make to a repository, so that
+// the changes made by a run can be reviewed and undone.
+//
+// A journal is a file of JSON entries, one per line, for each repository.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// Operations recorded in a journal.
+const (
+	OpCreate           = "create"
+	OpChangeBase       = "change-base"
+	OpClose            = "close"
+	OpEdit             = "edit"
+	OpMerge            = "merge"
+	OpEnqueue          = "enqueue"
+	OpEnableAutoMerge  = "enable-auto-merge"
+	OpDisableAutoMerge = "disable-auto-merge"
+	OpReady            = "ready"
+	OpDraft            = "draft"
+	OpRequestReviewers = "request-reviewers"
+	OpAddLabels        = "add-labels"
+	OpAddAssignees     = "add-assignees"
+	OpAddComment       = "add-comment"
+	OpPush             = "push"
+	OpDeleteBranch     = "delete-branch"
+)
+
+// Entry is a single change to a repository.
+type Entry struct {
+	// Run identifies the command invocation which made the change.
+	Run     string    `json:"run"`
+	Command string    `json:"command"`
+	Time    time.Time `json:"time"`
+	Op      string    `json:"op"`
+	// Number is the pull request changed, if any.
+	Number int `json:"number,omitempty"`
+	// Ref is the branch pushed or deleted, or the head of a created, closed,
+	// promoted or drafted pull request.
+	Ref string `json:"ref,omitempty"`
+	// Before and After are the state before and after the change: the base
+	// branch for OpCreate (After only) and OpChangeBase, the body for
+	// OpEdit, the head and merge commit SHAs for OpMerge, the old and new
+	// SHAs for OpPush, and the SHA of the deleted branch for OpDeleteBranch
+	// (Before only). For OpEnqueue and OpEnableAutoMerge Before is the head
+	// SHA, and After is the merge method of OpEnableAutoMerge or the body of
+	// OpAddComment.
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	// Added are the reviewers (with teams as "team:slug"), labels or
+	// assignees added by OpRequestReviewers, OpAddLabels or OpAddAssignees.
+	Added []string `json:"added,omitempty"`
+	// BeforeTitle and AfterTitle are the title before and after an OpEdit
+	// which changed it.
+	BeforeTitle string `json:"before_title,omitempty"`
+	AfterTitle  string `json:"after_title,omitempty"`
+}
+
+// Journal appends entries for one run of a command to a journal file.
+type Journal struct {
+	path    string
+	run     string
+	command string
+}
+
+// Dir returns the directory holding the journals:
+// $XDG_STATE_HOME/git-tools/journal, or ~/.local/state/git-tools/journal.
+func Dir() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".local", "state")
+	}
+	return filepath.Join(dir, "git-tools", "journal")
+}
+
+// Path returns the path of the journal for repository `owner`/`repo` in `dir`.
+func Path(dir, owner, repo string) string {
+	return filepath.Join(dir, owner, repo+".jsonl")
+}
+
+// Open returns a journal for a new run of `command` which appends to the file
+// at `path`.
+func Open(path, command string) *Journal {
+	run := fmt.Sprintf("%s-%d", time.Now().UTC().Format("20060102T150405"), os.Getpid())
+	glog.V(1).Infof("journaling run %s of %s to %s", run, command, path)
+	return &Journal{path: path, run: run, command: command}
+}
+
+// Run returns the ID of the run being journaled.
+func (j *Journal) Run() string {
+	return j.run
+}
+
+// Record appends `e` to the journal, filling in the run, command and time. A
+// nil journal records nothing.
+func (j *Journal) Record(e Entry) error {
+	if j == nil {
+		return nil
+	}
+	e.Run, e.Command, e.Time = j.run, j.command, time.Now()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %v", err)
+	}
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %v", err)
+	}
+	return nil
+}
+
+// Read returns the entries in the journal at `path`, oldest first. A missing
+// journal has no entries.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %v", err)
+	}
+	defer f.Close()
+	var entries []Entry
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1<<20)
+	for line := 1; s.Scan(); line++ {
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse journal %s line %d: %v", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %v", err)
+	}
+	return entries, nil
+}
+
+// ForRun returns the entries of `entries` made by run `run`.
+func ForRun(entries []Entry, run string) []Entry {
+	var res []Entry
+	for _, e := range entries {
+		if e.Run == run {
+			res = append(res, e)
+		}
+	}
+	return res
+}
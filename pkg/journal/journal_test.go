@@ -0,0 +1,244 @@
+package journal
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/google/go-github/v28/github"
+)
+
+// fakeRepo keeps pull requests and branches in memory and applies the base,
+// state, title, body, draft and branch changes made to them, so that a test
+// can check what Undo restored. The other changes are accepted and dropped.
+type fakeRepo struct {
+	repo.Repo
+	prs      map[int]*github.PullRequest
+	branches map[string]string
+}
+
+func (f *fakeRepo) PullRequest(num int) (*github.PullRequest, error) {
+	return f.prs[num], nil
+}
+
+func (f *fakeRepo) CreatePullRequest(npr *github.NewPullRequest) (*github.PullRequest, error) {
+	pr := &github.PullRequest{
+		Number: github.Int(len(f.prs) + 1),
+		State:  github.String("open"),
+		Title:  npr.Title,
+		Head:   &github.PullRequestBranch{Ref: npr.Head},
+		Base:   &github.PullRequestBranch{Ref: npr.Base},
+	}
+	f.prs[pr.GetNumber()] = pr
+	return pr, nil
+}
+
+func (f *fakeRepo) ChangePullRequestBase(num int, ref string) error {
+	f.prs[num].Base.Ref = github.String(ref)
+	return nil
+}
+
+func (f *fakeRepo) EditPullRequest(num int, pr *github.PullRequest) (*github.PullRequest, error) {
+	if pr.State != nil {
+		f.prs[num].State = pr.State
+	}
+	if pr.Title != nil {
+		f.prs[num].Title = pr.Title
+	}
+	if pr.Body != nil {
+		f.prs[num].Body = pr.Body
+	}
+	return f.prs[num], nil
+}
+
+func (f *fakeRepo) MarkReadyForReview(num int) error {
+	f.prs[num].Draft = github.Bool(false)
+	return nil
+}
+
+func (f *fakeRepo) ConvertToDraft(num int) error {
+	f.prs[num].Draft = github.Bool(true)
+	return nil
+}
+
+func (f *fakeRepo) EnqueuePullRequest(num int, sha string) error {
+	return nil
+}
+
+func (f *fakeRepo) EnableAutoMerge(num int, sha, method, msg string) error {
+	return nil
+}
+
+func (f *fakeRepo) DisableAutoMerge(num int) error {
+	return nil
+}
+
+func (f *fakeRepo) RequestReviewers(num int, reviewers, teams []string) error {
+	return nil
+}
+
+func (f *fakeRepo) AddLabels(num int, labels []string) error {
+	return nil
+}
+
+func (f *fakeRepo) AddAssignees(num int, assignees []string) error {
+	return nil
+}
+
+func (f *fakeRepo) AddComment(num int, body string) error {
+	return nil
+}
+
+func (f *fakeRepo) Branch(name string) (*github.Branch, error) {
+	sha, ok := f.branches[name]
+	if !ok {
+		return nil, fmt.Errorf("branch %s not found", name)
+	}
+	return &github.Branch{Name: github.String(name), Commit: &github.RepositoryCommit{SHA: github.String(sha)}}, nil
+}
+
+func (f *fakeRepo) DeleteBranch(name string) error {
+	delete(f.branches, name)
+	return nil
+}
+
+func TestJournalAndUndo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owner", "repo.jsonl")
+	f := &fakeRepo{prs: make(map[int]*github.PullRequest), branches: map[string]string{"master": "0", "e": "3"}}
+
+	before := Wrap(f, Open(path, "setup"))
+	for _, branch := range []string{"a", "b", "c"} {
+		if _, err := before.CreatePullRequest(&github.NewPullRequest{Title: github.String(branch), Head: github.String(branch), Base: github.String("master")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	j := Open(path, "test")
+	j.run += "-test"
+	r := Wrap(f, j)
+	if err := r.ChangePullRequestBase(2, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ChangePullRequestBase(3, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreatePullRequest(&github.NewPullRequest{Head: github.String("d"), Base: github.String("c")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Record(Entry{Op: OpPush, Ref: "d", Before: "1", After: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.EditPullRequest(1, &github.PullRequest{State: github.String("closed")}); err != nil {
+		t.Fatal(err)
+	}
+	// PR 5 is moved off branch e, which is then deleted.
+	f.prs[5] = &github.PullRequest{Number: github.Int(5), State: github.String("open"), Title: github.String("f"), Head: &github.PullRequestBranch{Ref: github.String("f")}, Base: &github.PullRequestBranch{Ref: github.String("e")}}
+	if err := r.ChangePullRequestBase(5, "master"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteBranch("e"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.EditPullRequest(2, &github.PullRequest{Title: github.String("B"), Body: github.String("new")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.EditPullRequest(3, &github.PullRequest{Body: github.String("new")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ConvertToDraft(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.MarkReadyForReview(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RequestReviewers(4, []string{"alice"}, []string{"core"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddLabels(4, []string{"stacked"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddAssignees(4, []string{"me"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddComment(4, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.EnableAutoMerge(4, "2", "squash", "msg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DisableAutoMerge(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.EnqueuePullRequest(4, "2"); err != nil {
+		t.Fatal(err)
+	}
+	// Someone else changes PR 3 after the run.
+	f.prs[3].Base.Ref = github.String("other")
+	f.prs[3].Body = github.String("theirs")
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	run := ForRun(entries, j.Run())
+	var got []Entry
+	for _, e := range run {
+		got = append(got, Entry{Op: e.Op, Number: e.Number, Ref: e.Ref, Before: e.Before, After: e.After, BeforeTitle: e.BeforeTitle, AfterTitle: e.AfterTitle, Added: e.Added})
+	}
+	want := []Entry{
+		{Op: OpChangeBase, Number: 2, Before: "master", After: "a"},
+		{Op: OpChangeBase, Number: 3, Before: "master", After: "b"},
+		{Op: OpCreate, Number: 4, Ref: "d", After: "c"},
+		{Op: OpPush, Ref: "d", Before: "1", After: "2"},
+		{Op: OpClose, Number: 1, Ref: "a"},
+		{Op: OpChangeBase, Number: 5, Before: "e", After: "master"},
+		{Op: OpDeleteBranch, Ref: "e", Before: "3"},
+		{Op: OpEdit, Number: 2, Ref: "b", Before: "", After: "new", BeforeTitle: "b", AfterTitle: "B"},
+		{Op: OpEdit, Number: 3, Ref: "c", Before: "", After: "new"},
+		{Op: OpDraft, Number: 2},
+		{Op: OpReady, Number: 4},
+		{Op: OpRequestReviewers, Number: 4, Added: []string{"alice", "team:core"}},
+		{Op: OpAddLabels, Number: 4, Added: []string{"stacked"}},
+		{Op: OpAddAssignees, Number: 4, Added: []string{"me"}},
+		{Op: OpAddComment, Number: 4, After: "hello"},
+		{Op: OpEnableAutoMerge, Number: 4, Before: "2", After: "squash"},
+		{Op: OpDisableAutoMerge, Number: 4},
+		{Op: OpEnqueue, Number: 4, Before: "2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("journal for run = %+v, want %+v", got, want)
+	}
+
+	if err := Undo(f, run); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if base := f.prs[2].GetBase().GetRef(); base != "master" {
+		t.Errorf("PR 2 base = %s after undo, want master", base)
+	}
+	if base := f.prs[3].GetBase().GetRef(); base != "other" {
+		t.Errorf("PR 3 base = %s after undo, want other", base)
+	}
+	if base := f.prs[5].GetBase().GetRef(); base != "master" {
+		t.Errorf("PR 5 base = %s after undo, want master since e is gone", base)
+	}
+	if state := f.prs[4].GetState(); state != "closed" {
+		t.Errorf("PR 4 state = %s after undo, want closed", state)
+	}
+	if state := f.prs[1].GetState(); state != "open" {
+		t.Errorf("PR 1 state = %s after undo, want open", state)
+	}
+	if title, body := f.prs[2].GetTitle(), f.prs[2].GetBody(); title != "b" || body != "" {
+		t.Errorf("PR 2 title, body = %q, %q after undo, want b and empty", title, body)
+	}
+	if body := f.prs[3].GetBody(); body != "theirs" {
+		t.Errorf("PR 3 body = %q after undo, want theirs", body)
+	}
+	if f.prs[2].GetDraft() {
+		t.Errorf("PR 2 is a draft after undo, want ready for review")
+	}
+	if !f.prs[4].GetDraft() {
+		t.Errorf("PR 4 is ready for review after undo, want a draft")
+	}
+}
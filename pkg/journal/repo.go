@@ -0,0 +1,179 @@
+package journal
+
+import (
+	"fmt"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// Repo is a repo.Repo which records the pull requests it creates, retargets,
+// edits, closes, merges, marks ready for review and converts to drafts, and the
+// branches it deletes, in a journal.
+type Repo struct {
+	repo.Repo
+	j *Journal
+}
+
+// Wrap returns `r`, recording its changes in `j`. If `j` is nil it returns `r`
+// itself.
+func Wrap(r repo.Repo, j *Journal) repo.Repo {
+	if j == nil {
+		return r
+	}
+	return &Repo{Repo: r, j: j}
+}
+
+// record records `e`. The change has already been made, so failing to record
+// it only produces a warning.
+func (r *Repo) record(e Entry) {
+	if err := r.j.Record(e); err != nil {
+		glog.Warningf("failed to journal %s of %d: %v", e.Op, e.Number, err)
+	}
+}
+
+func (r *Repo) CreatePullRequest(npr *github.NewPullRequest) (*github.PullRequest, error) {
+	pr, err := r.Repo.CreatePullRequest(npr)
+	if err != nil {
+		return nil, err
+	}
+	r.record(Entry{Op: OpCreate, Number: pr.GetNumber(), Ref: npr.GetHead(), After: npr.GetBase()})
+	return pr, nil
+}
+
+func (r *Repo) ChangePullRequestBase(num int, ref string) error {
+	pr, err := r.Repo.PullRequest(num)
+	if err != nil {
+		return fmt.Errorf("failed to get pr %d before changing its base: %v", num, err)
+	}
+	before := pr.GetBase().GetRef()
+	if err := r.Repo.ChangePullRequestBase(num, ref); err != nil {
+		return err
+	}
+	r.record(Entry{Op: OpChangeBase, Number: num, Before: before, After: ref})
+	return nil
+}
+
+func (r *Repo) MergePullRequest(num int, sha, method, msg string) (*github.PullRequest, error) {
+	pr, err := r.Repo.MergePullRequest(num, sha, method, msg)
+	if err != nil {
+		return nil, err
+	}
+	r.record(Entry{Op: OpMerge, Number: num, Before: sha, After: pr.GetMergeCommitSHA()})
+	return pr, nil
+}
+
+func (r *Repo) EnqueuePullRequest(num int, sha string) error {
+	if err := r.Repo.EnqueuePullRequest(num, sha); err != nil {
+		return err
+	}
+	r.record(Entry{Op: OpEnqueue, Number: num, Before: sha})
+	return nil
+}
+
+func (r *Repo) EnableAutoMerge(num int, sha, method, msg string) error {
+	if err := r.Repo.EnableAutoMerge(num, sha, method, msg); err != nil {
+		return err
+	}
+	r.record(Entry{Op: OpEnableAutoMerge, Number: num, Before: sha, After: method})
+	return nil
+}
+
+func (r *Repo) DisableAutoMerge(num int) error {
+	if err := r.Repo.DisableAutoMerge(num); err != nil {
+		return err
+	}
+	r.record(Entry{Op: OpDisableAutoMerge, Number: num})
+	return nil
+}
+
+func (r *Repo) MarkReadyForReview(num int) error {
+	if err := r.Repo.MarkReadyForReview(num); err != nil {
+		return err
+	}
+	r.record(Entry{Op: OpReady, Number: num})
+	return nil
+}
+
+func (r *Repo) ConvertToDraft(num int) error {
+	if err := r.Repo.ConvertToDraft(num); err != nil {
+		return err
+	}
+	r.record(Entry{Op: OpDraft, Number: num})
+	return nil
+}
+
+func (r *Repo) EditPullRequest(num int, edit *github.PullRequest) (*github.PullRequest, error) {
+	pr, err := r.Repo.PullRequest(num)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pr %d before editing it: %v", num, err)
+	}
+	head, state, title, body := pr.GetHead().GetRef(), pr.GetState(), pr.GetTitle(), pr.GetBody()
+	updated, err := r.Repo.EditPullRequest(num, edit)
+	if err != nil {
+		return nil, err
+	}
+	if edit.Title != nil || edit.Body != nil {
+		e := Entry{Op: OpEdit, Number: num, Ref: head, Before: body, After: body}
+		if edit.Body != nil {
+			e.After = edit.GetBody()
+		}
+		if edit.Title != nil {
+			e.BeforeTitle, e.AfterTitle = title, edit.GetTitle()
+		}
+		r.record(e)
+	}
+	if edit.GetState() == "closed" && state != "closed" {
+		r.record(Entry{Op: OpClose, Number: num, Ref: head})
+	}
+	return updated, nil
+}
+
+func (r *Repo) RequestReviewers(num int, reviewers, teams []string) error {
+	if err := r.Repo.RequestReviewers(num, reviewers, teams); err != nil {
+		return err
+	}
+	added := append([]string(nil), reviewers...)
+	for _, t := range teams {
+		added = append(added, "team:"+t)
+	}
+	r.record(Entry{Op: OpRequestReviewers, Number: num, Added: added})
+	return nil
+}
+
+func (r *Repo) AddLabels(num int, labels []string) error {
+	if err := r.Repo.AddLabels(num, labels); err != nil {
+		return err
+	}
+	r.record(Entry{Op: OpAddLabels, Number: num, Added: labels})
+	return nil
+}
+
+func (r *Repo) AddAssignees(num int, assignees []string) error {
+	if err := r.Repo.AddAssignees(num, assignees); err != nil {
+		return err
+	}
+	r.record(Entry{Op: OpAddAssignees, Number: num, Added: assignees})
+	return nil
+}
+
+func (r *Repo) AddComment(num int, body string) error {
+	if err := r.Repo.AddComment(num, body); err != nil {
+		return err
+	}
+	r.record(Entry{Op: OpAddComment, Number: num, After: body})
+	return nil
+}
+
+func (r *Repo) DeleteBranch(name string) error {
+	b, err := r.Repo.Branch(name)
+	if err != nil {
+		return fmt.Errorf("failed to get branch %s before deleting it: %v", name, err)
+	}
+	if err := r.Repo.DeleteBranch(name); err != nil {
+		return err
+	}
+	r.record(Entry{Op: OpDeleteBranch, Ref: name, Before: b.GetCommit().GetSHA()})
+	return nil
+}
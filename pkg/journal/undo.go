@@ -0,0 +1,153 @@
+package journal
+
+import (
+	"fmt"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// Undo reverts the changes in `entries`, newest first. Pull requests whose
+// base was changed get their old base back, unless the base has changed again
+// since or the old base branch no longer exists, pull requests which were
+// created are closed, unless they were merged, pull requests which were closed
+// are reopened, pull requests whose title or body was edited get the old ones
+// back unless they were edited again since, and pull requests which were
+// marked ready for review or converted to drafts are changed back if they are
+// still open. Merges, pushes, branch deletions,
+// merge queue and auto-merge changes, and added reviewers, labels, assignees
+// and comments cannot be undone, so they are only reported.
+func Undo(r repo.Repo, entries []Entry) error {
+	failed := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		switch e.Op {
+		case OpChangeBase:
+			pr, err := r.PullRequest(e.Number)
+			if err != nil {
+				glog.Warningf("failed to get PR %d: %v", e.Number, err)
+				failed++
+				continue
+			}
+			if base := pr.GetBase().GetRef(); base != e.After {
+				glog.Warningf("not changing base of PR %d back to %s: its base is now %s, not %s", e.Number, e.Before, base, e.After)
+				continue
+			}
+			// The old base is gone if the run deleted it, e.g. drop-pr
+			// with -delete-branch.
+			if _, err := r.Branch(e.Before); err != nil {
+				glog.Warningf("not changing base of PR %d back to %s: the branch cannot be read, restore it first: %v", e.Number, e.Before, err)
+				continue
+			}
+			glog.Infof("changing base of PR %d back to %s", e.Number, e.Before)
+			if err := r.ChangePullRequestBase(e.Number, e.Before); err != nil {
+				glog.Warningf("failed to change base of PR %d: %v", e.Number, err)
+				failed++
+			}
+		case OpCreate:
+			pr, err := r.PullRequest(e.Number)
+			if err != nil {
+				glog.Warningf("failed to get PR %d: %v", e.Number, err)
+				failed++
+				continue
+			}
+			if pr.GetState() != "open" {
+				glog.Warningf("not closing PR %d: it is %s", e.Number, pr.GetState())
+				continue
+			}
+			glog.Infof("closing PR %d for %s", e.Number, e.Ref)
+			if _, err := r.EditPullRequest(e.Number, &github.PullRequest{State: github.String("closed")}); err != nil {
+				glog.Warningf("failed to close PR %d: %v", e.Number, err)
+				failed++
+			}
+		case OpClose:
+			pr, err := r.PullRequest(e.Number)
+			if err != nil {
+				glog.Warningf("failed to get PR %d: %v", e.Number, err)
+				failed++
+				continue
+			}
+			if pr.GetState() != "closed" || pr.GetMerged() {
+				glog.Warningf("not reopening PR %d: it is %s", e.Number, stack.State(pr))
+				continue
+			}
+			glog.Infof("reopening PR %d for %s", e.Number, e.Ref)
+			if _, err := r.EditPullRequest(e.Number, &github.PullRequest{State: github.String("open")}); err != nil {
+				// GitHub cannot reopen a pull request whose head branch is
+				// gone, see the warning about restoring it.
+				glog.Warningf("failed to reopen PR %d: %v", e.Number, err)
+				failed++
+			}
+		case OpEdit:
+			pr, err := r.PullRequest(e.Number)
+			if err != nil {
+				glog.Warningf("failed to get PR %d: %v", e.Number, err)
+				failed++
+				continue
+			}
+			if pr.GetBody() != e.After || (e.AfterTitle != "" && pr.GetTitle() != e.AfterTitle) {
+				glog.Warningf("not restoring title and body of PR %d: they have been edited again since", e.Number)
+				continue
+			}
+			glog.Infof("restoring title and body of PR %d", e.Number)
+			edit := &github.PullRequest{Body: github.String(e.Before)}
+			if e.BeforeTitle != "" {
+				edit.Title = github.String(e.BeforeTitle)
+			}
+			if _, err := r.EditPullRequest(e.Number, edit); err != nil {
+				glog.Warningf("failed to restore title and body of PR %d: %v", e.Number, err)
+				failed++
+			}
+		case OpReady, OpDraft:
+			pr, err := r.PullRequest(e.Number)
+			if err != nil {
+				glog.Warningf("failed to get PR %d: %v", e.Number, err)
+				failed++
+				continue
+			}
+			toDraft := e.Op == OpReady
+			if pr.GetState() != "open" || pr.GetDraft() == toDraft {
+				glog.Warningf("not changing PR %d back: it is %s", e.Number, stack.State(pr))
+				continue
+			}
+			glog.Infof("changing PR %d back from %s", e.Number, e.Op)
+			if toDraft {
+				err = r.ConvertToDraft(e.Number)
+			} else {
+				err = r.MarkReadyForReview(e.Number)
+			}
+			if err != nil {
+				glog.Warningf("failed to change PR %d back from %s: %v", e.Number, e.Op, err)
+				failed++
+			}
+		case OpMerge:
+			glog.Warningf("PR %d was merged as %s, which cannot be undone; revert it with a new PR", e.Number, e.After)
+		case OpEnqueue:
+			glog.Warningf("PR %d was added to the merge queue, which cannot be undone; remove it from the queue if it has not been merged yet", e.Number)
+		case OpEnableAutoMerge:
+			glog.Warningf("auto-merge (%s) of PR %d was enabled, which cannot be undone; disable it with `submit-pr --disable-auto --pr=%d`", e.After, e.Number, e.Number)
+		case OpDisableAutoMerge:
+			glog.Warningf("auto-merge of PR %d was disabled, which cannot be undone; enable it again with `submit-pr --auto --pr=%d`", e.Number, e.Number)
+		case OpRequestReviewers:
+			glog.Warningf("reviews of PR %d were requested from %v, which cannot be undone", e.Number, e.Added)
+		case OpAddLabels:
+			glog.Warningf("labels %v were added to PR %d, which cannot be undone", e.Added, e.Number)
+		case OpAddAssignees:
+			glog.Warningf("assignees %v were added to PR %d, which cannot be undone", e.Added, e.Number)
+		case OpAddComment:
+			glog.Warningf("a comment was added to PR %d, which cannot be undone; delete it if it is no longer wanted", e.Number)
+		case OpPush:
+			glog.Warningf("%s was pushed from %s to %s, which is not undone; restore it with `git push --force-with-lease=%s:%s <remote> %s:refs/heads/%s`", e.Ref, e.Before, e.After, e.Ref, e.After, e.Before, e.Ref)
+		case OpDeleteBranch:
+			glog.Warningf("%s was deleted at %s, which is not undone; restore it with `git push <remote> %s:refs/heads/%s`", e.Ref, e.Before, e.Before, e.Ref)
+		default:
+			glog.Warningf("unknown journal operation %q", e.Op)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d changes could not be undone", failed)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Package message builds the commit messages used when submitting pull
+// requests, from a text/template.
+package message
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/bretmckee/git-tools/pkg/stack"
+)
+
+// DefaultTemplate uses the pull request body as the message when there is a
+// single commit, and otherwise lists the message of every commit.
+const DefaultTemplate = `{{if lt (len .Commits) 2}}{{.Body}}{{else}}{{range .Commits}}* {{.Message}}
+
+{{end}}{{end}}`
+
+// Commit is a single commit of a pull request.
+type Commit struct {
+	SHA         string
+	Message     string
+	AuthorName  string
+	AuthorEmail string
+	// AuthorLogin is the GitHub login of the author, if GitHub could match
+	// the commit to a user.
+	AuthorLogin string
+}
+
+// Author returns the commit author formatted as "Name <email>".
+func (c Commit) Author() string {
+	return fmt.Sprintf("%s <%s>", c.AuthorName, c.AuthorEmail)
+}
+
+// Data is the data available to a message template.
+type Data struct {
+	Number int
+	Title  string
+	// Body is the pull request body without the stack navigation section or
+	// hidden comments added by git-tools.
+	Body string
+	URL  string
+	// Commits are the commits of the pull request, oldest first.
+	Commits []Commit
+	// Authors are the distinct commit authors, formatted as "Name <email>",
+	// in the order they first appear in Commits.
+	Authors []string
+}
+
+// commentLineRE matches lines which consist only of an HTML comment.
+var commentLineRE = regexp.MustCompile(`(?m)^[ \t]*<!--.*-->[ \t]*\n?`)
+
+// CleanBody returns `body` without its stack navigation section or any lines
+// which hold only an HTML comment, neither of which belong in a commit message.
+func CleanBody(body string) string {
+	if nav := stack.FindNavigation(body); nav != "" {
+		body = strings.Replace(body, nav, "", 1)
+	}
+	body = strings.TrimRight(commentLineRE.ReplaceAllString(body, ""), "\n")
+	if body == "" {
+		return ""
+	}
+	return body + "\n"
+}
+
+// NewData returns the template data for a pull request, filling in Authors and
+// cleaning `body`.
+func NewData(number int, title, body, url string, commits []Commit) *Data {
+	d := &Data{
+		Number:  number,
+		Title:   title,
+		Body:    CleanBody(body),
+		URL:     url,
+		Commits: commits,
+	}
+	seen := make(map[string]bool)
+	for _, c := range commits {
+		if a := c.Author(); !seen[a] {
+			seen[a] = true
+			d.Authors = append(d.Authors, a)
+		}
+	}
+	return d
+}
+
+// Render executes template `tmpl` with `d`.
+func Render(tmpl string, d *Data) (string, error) {
+	t, err := template.New("message").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse message template: %v", err)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, d); err != nil {
+		return "", fmt.Errorf("failed to execute message template: %v", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,269 @@
+package message
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/stack"
+	"github.com/google/go-github/v28/github"
+)
+
+func TestRender(t *testing.T) {
+	one := []Commit{{SHA: "1", Message: "First\n\nDetails.", AuthorName: "A", AuthorEmail: "a@example.com"}}
+	two := append(one, Commit{SHA: "2", Message: "Second", AuthorName: "A", AuthorEmail: "a@example.com"})
+	body := "Body text.\n\n" + stack.NavigationBegin + "\nstack\n" + stack.NavigationEnd + "\n<!-- git-tools:source:0123 -->\n"
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		commits []Commit
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "default single commit uses body",
+			tmpl:    DefaultTemplate,
+			commits: one,
+			want:    "Body text.\n",
+		},
+		{
+			name:    "default lists commits",
+			tmpl:    DefaultTemplate,
+			commits: two,
+			want:    "* First\n\nDetails.\n\n* Second\n\n",
+		},
+		{
+			name:    "fields",
+			tmpl:    "{{.Title}} (#{{.Number}})\n\n{{.URL}}\n{{range .Authors}}{{.}}\n{{end}}",
+			commits: two,
+			want:    "Title (#7)\n\nhttps://example.com/pull/7\nA <a@example.com>\n",
+		},
+		{
+			name:    "bad template",
+			tmpl:    "{{.Missing}",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			tmpl:    "{{.Missing}}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.tmpl, NewData(7, "Title", body, "https://example.com/pull/7", tt.commits))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendTrailers(t *testing.T) {
+	alice := Person{Name: "Alice", Email: "alice@example.com"}
+	bob := Person{Name: "Bob", Email: "bob@example.com"}
+	tests := []struct {
+		name      string
+		msg       string
+		reviewers []Person
+		coAuthors []Person
+		want      string
+	}{
+		{
+			name: "nothing to add",
+			msg:  "Body.\n",
+			want: "Body.\n",
+		},
+		{
+			name:      "new block",
+			msg:       "Body.\n",
+			reviewers: []Person{alice},
+			coAuthors: []Person{bob},
+			want:      "Body.\n\nReviewed-by: Alice <alice@example.com>\nCo-authored-by: Bob <bob@example.com>\n",
+		},
+		{
+			name:      "extends existing block and skips duplicates",
+			msg:       "Subject\n\nBody.\n\nCo-authored-by: Bob <bob@example.com>\n",
+			reviewers: []Person{alice, alice},
+			coAuthors: []Person{bob},
+			want:      "Subject\n\nBody.\n\nCo-authored-by: Bob <bob@example.com>\nReviewed-by: Alice <alice@example.com>\n",
+		},
+		{
+			name:      "empty message",
+			reviewers: []Person{alice},
+			want:      "Reviewed-by: Alice <alice@example.com>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AppendTrailers(tt.msg, tt.reviewers, tt.coAuthors); got != tt.want {
+				t.Errorf("AppendTrailers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserPerson(t *testing.T) {
+	u := &github.User{ID: github.Int64(42), Login: github.String("octo")}
+	want := Person{Name: "octo", Email: "42+octo@users.noreply.github.com"}
+	if got := UserPerson(u); got != want {
+		t.Errorf("UserPerson() = %v, want %v", got, want)
+	}
+	if !IsUser(u, "Someone", "42+Octo@users.noreply.github.com") {
+		t.Errorf("IsUser() = false for the no-reply address")
+	}
+	if IsUser(u, "Someone", "someone@example.com") {
+		t.Errorf("IsUser() = true for a different author")
+	}
+}
+
+// usersRepo resolves the logins in `users`, and fails for any other login.
+type usersRepo struct {
+	repo.Repo
+	users map[string]*github.User
+}
+
+func (u *usersRepo) User(login string) (*github.User, error) {
+	if user, ok := u.users[login]; ok {
+		return user, nil
+	}
+	return nil, fmt.Errorf("no user %q", login)
+}
+
+func TestCredits(t *testing.T) {
+	r := &usersRepo{users: map[string]*github.User{
+		"me":    {ID: github.Int64(1), Login: github.String("me"), Name: github.String("Me")},
+		"alice": {ID: github.Int64(2), Login: github.String("alice"), Name: github.String("Alice"), Email: github.String("alice@example.com")},
+		"bob":   {ID: github.Int64(3), Login: github.String("bob")},
+	}}
+	pr := &github.PullRequest{User: &github.User{Login: github.String("me")}}
+	alice := Person{Name: "Alice", Email: "alice@example.com"}
+	bob := Person{Name: "bob", Email: "3+bob@users.noreply.github.com"}
+	carol := Person{Name: "Carol", Email: "carol@example.com"}
+
+	tests := []struct {
+		name          string
+		approvers     []string
+		commits       []Commit
+		wantReviewers []Person
+		wantCoAuthors []Person
+	}{
+		{
+			name:          "pr author is not a co-author",
+			approvers:     []string{"alice"},
+			commits:       []Commit{{AuthorName: "Me", AuthorEmail: "me@work.example.com", AuthorLogin: "me"}, {AuthorName: "Me", AuthorEmail: "me@home.example.com"}},
+			wantReviewers: []Person{alice},
+		},
+		{
+			name: "co-authors are resolved by login",
+			commits: []Commit{
+				{AuthorName: "Bob Smith", AuthorEmail: "bob@work.example.com", AuthorLogin: "bob"},
+				{AuthorName: "Bob", AuthorEmail: "bob@home.example.com", AuthorLogin: "bob"},
+			},
+			wantCoAuthors: []Person{bob},
+		},
+		{
+			name: "emails without a login are deduplicated",
+			commits: []Commit{
+				{AuthorName: "Carol", AuthorEmail: "carol@example.com"},
+				{AuthorName: "Alice", AuthorEmail: "alice@example.com"},
+				{AuthorName: "Alice", AuthorEmail: "alice@example.com", AuthorLogin: "alice"},
+				{AuthorName: "Carol", AuthorEmail: "carol@example.com"},
+			},
+			wantCoAuthors: []Person{alice, carol},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reviewers, coAuthors, err := Credits(r, pr, tt.approvers, tt.commits)
+			if err != nil {
+				t.Fatalf("Credits() error = %v", err)
+			}
+			if !reflect.DeepEqual(reviewers, tt.wantReviewers) {
+				t.Errorf("Credits() reviewers = %v, want %v", reviewers, tt.wantReviewers)
+			}
+			if !reflect.DeepEqual(coAuthors, tt.wantCoAuthors) {
+				t.Errorf("Credits() co-authors = %v, want %v", coAuthors, tt.wantCoAuthors)
+			}
+		})
+	}
+}
+
+// commitsRepo lists the same commits for every pull request.
+type commitsRepo struct {
+	repo.Repo
+	commits []*github.RepositoryCommit
+}
+
+func (c *commitsRepo) PullRequestCommits(num int) ([]*github.RepositoryCommit, error) {
+	return c.commits, nil
+}
+
+func TestCommits(t *testing.T) {
+	commit := func(sha string, parents int) *github.RepositoryCommit {
+		rc := &github.RepositoryCommit{SHA: github.String(sha), Commit: &github.Commit{Message: github.String("commit " + sha)}}
+		for i := 0; i < parents; i++ {
+			rc.Parents = append(rc.Parents, github.Commit{SHA: github.String(fmt.Sprintf("%s^%d", sha, i+1))})
+		}
+		return rc
+	}
+	tests := []struct {
+		name        string
+		total       int
+		listed      []*github.RepositoryCommit
+		want        []string
+		wantWarning string
+	}{
+		{
+			name:   "all listed",
+			total:  2,
+			listed: []*github.RepositoryCommit{commit("1", 1), commit("2", 1)},
+			want:   []string{"1", "2"},
+		},
+		{
+			name:   "merge commits left out",
+			total:  3,
+			listed: []*github.RepositoryCommit{commit("1", 1), commit("m", 2), commit("2", 1)},
+			want:   []string{"1", "2"},
+		},
+		{
+			name:        "truncated",
+			total:       300,
+			listed:      []*github.RepositoryCommit{commit("1", 1), commit("m", 2)},
+			want:        []string{"1"},
+			wantWarning: "GitHub listed only 2 of the 300 commits in PR 7",
+		},
+	}
+	defer func(f func(string, ...interface{})) { warningf = f }(warningf)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			warningf = func(format string, args ...interface{}) {
+				warnings = append(warnings, fmt.Sprintf(format, args...))
+			}
+			pr := &github.PullRequest{Number: github.Int(7), Commits: github.Int(tt.total)}
+			commits, err := Commits(&commitsRepo{commits: tt.listed}, pr)
+			if err != nil {
+				t.Fatalf("Commits() error = %v", err)
+			}
+			var got []string
+			for _, c := range commits {
+				got = append(got, c.SHA)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Commits() = %v, want %v", got, tt.want)
+			}
+			if got := strings.Join(warnings, "\n"); got != tt.wantWarning {
+				t.Errorf("Commits() warned %q, want %q", got, tt.wantWarning)
+			}
+		})
+	}
+}
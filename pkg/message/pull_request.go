@@ -0,0 +1,123 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+	"github.com/kr/pretty"
+)
+
+// warningf logs a warning. Tests replace it to check the warnings.
+var warningf = glog.Warningf
+
+// Commits returns the commits of `pr`, leaving out merge commits.
+func Commits(r repo.Repo, pr *github.PullRequest) ([]Commit, error) {
+	listed, err := r.PullRequestCommits(pr.GetNumber())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %v", err)
+	}
+	if n := pr.GetCommits(); len(listed) < n {
+		warningf("GitHub listed only %d of the %d commits in PR %d", len(listed), n, pr.GetNumber())
+	}
+	var commits []Commit
+	for _, commit := range listed {
+		glog.V(2).Infof("processing commit: %s", pretty.Sprintf("%# v", commit))
+		if parents := len(commit.Parents); parents > 1 {
+			glog.V(1).Infof("leaving merge commit %s out of the message", commit.GetSHA())
+			continue
+		}
+		commits = append(commits, Commit{
+			SHA:         commit.GetSHA(),
+			Message:     commit.GetCommit().GetMessage(),
+			AuthorName:  commit.GetCommit().GetAuthor().GetName(),
+			AuthorEmail: commit.GetCommit().GetAuthor().GetEmail(),
+			AuthorLogin: commit.GetAuthor().GetLogin(),
+		})
+	}
+	return commits, nil
+}
+
+// Credits resolves the people to credit in the merge commit of `pr`: the
+// reviewers with logins `approvers`, and the authors of `commits` other than the
+// author of the pull request. Commit authors with a known login are resolved
+// through the API and credited once per login, whichever email they used.
+func Credits(r repo.Repo, pr *github.PullRequest, approvers []string, commits []Commit) ([]Person, []Person, error) {
+	var reviewers []Person
+	for _, login := range approvers {
+		u, err := r.User(login)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve reviewer: %v", err)
+		}
+		reviewers = append(reviewers, UserPerson(u))
+	}
+
+	author, err := r.User(pr.GetUser().GetLogin())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve pr author: %v", err)
+	}
+	// Commits with a login are resolved first, so that an email without one
+	// can be recognised as belonging to a user who is already credited.
+	users := []*github.User{author}
+	var coAuthors []Person
+	seen := map[string]bool{strings.ToLower(author.GetLogin()): true}
+	for _, commit := range commits {
+		login := strings.ToLower(commit.AuthorLogin)
+		if login == "" || seen[login] {
+			continue
+		}
+		seen[login] = true
+		u, err := r.User(commit.AuthorLogin)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve commit author: %v", err)
+		}
+		users = append(users, u)
+		coAuthors = append(coAuthors, UserPerson(u))
+	}
+	for _, commit := range commits {
+		if commit.AuthorLogin != "" || seen[commit.AuthorEmail] || isAnyUser(users, commit.AuthorName, commit.AuthorEmail) {
+			continue
+		}
+		seen[commit.AuthorEmail] = true
+		coAuthors = append(coAuthors, Person{Name: commit.AuthorName, Email: commit.AuthorEmail})
+	}
+	return reviewers, coAuthors, nil
+}
+
+// isAnyUser reports whether the commit author `name`/`email` is one of `users`.
+func isAnyUser(users []*github.User, name, email string) bool {
+	for _, u := range users {
+		if IsUser(u, name, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForPullRequest returns the merge commit message for `pr`: `tmpl` (or
+// DefaultTemplate if it is empty) executed for the pull request, followed by
+// credit trailers for `approvers` and the commit authors if `credit` is true.
+func ForPullRequest(r repo.Repo, tmpl string, pr *github.PullRequest, approvers []string, credit bool) (string, error) {
+	commits, err := Commits(r, pr)
+	if err != nil {
+		return "", err
+	}
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+	d := NewData(pr.GetNumber(), pr.GetTitle(), pr.GetBody(), pr.GetHTMLURL(), commits)
+	msg, err := Render(tmpl, d)
+	if err != nil {
+		return "", err
+	}
+	if credit {
+		reviewers, coAuthors, err := Credits(r, pr, approvers, commits)
+		if err != nil {
+			return "", err
+		}
+		msg = AppendTrailers(msg, reviewers, coAuthors)
+	}
+	return msg, nil
+}
@@ -0,0 +1,99 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bretmckee/git-tools/pkg/trailers"
+	"github.com/google/go-github/v28/github"
+)
+
+// Trailer keys added to merge commit messages.
+const (
+	ReviewedBy   = "Reviewed-by"
+	CoAuthoredBy = "Co-authored-by"
+)
+
+// Person is someone credited in a trailer.
+type Person struct {
+	Name  string
+	Email string
+}
+
+func (p Person) String() string {
+	return fmt.Sprintf("%s <%s>", p.Name, p.Email)
+}
+
+// NoReplyEmail returns the GitHub no-reply address of `u`, which GitHub
+// attributes to the user even when their email address is private.
+func NoReplyEmail(u *github.User) string {
+	return fmt.Sprintf("%d+%s@users.noreply.github.com", u.GetID(), u.GetLogin())
+}
+
+// UserPerson returns the name and email of `u`, falling back to the login and
+// no-reply address when they are not public.
+func UserPerson(u *github.User) Person {
+	p := Person{Name: u.GetName(), Email: u.GetEmail()}
+	if p.Name == "" {
+		p.Name = u.GetLogin()
+	}
+	if p.Email == "" {
+		p.Email = NoReplyEmail(u)
+	}
+	return p
+}
+
+// IsUser reports whether the commit author `name`/`email` is `u`.
+func IsUser(u *github.User, name, email string) bool {
+	email = strings.ToLower(email)
+	switch {
+	case email != "" && email == strings.ToLower(u.GetEmail()):
+		return true
+	case email == strings.ToLower(NoReplyEmail(u)):
+		return true
+	case email == strings.ToLower(u.GetLogin()+"@users.noreply.github.com"):
+		return true
+	case name != "" && (name == u.GetName() || name == u.GetLogin()):
+		return true
+	}
+	return false
+}
+
+// AppendTrailers adds a Reviewed-by trailer for each of `reviewers` and a
+// Co-authored-by trailer for each of `coAuthors` to `msg`. People who already
+// have the same trailer, in `msg` or earlier in the lists, are skipped.
+func AppendTrailers(msg string, reviewers, coAuthors []Person) string {
+	existing := trailers.Parse(msg).Trailers
+	seen := make(map[string]bool)
+	for _, t := range existing {
+		seen[strings.ToLower(t.Key+": "+t.Value)] = true
+	}
+	var add trailers.Trailers
+	for _, l := range []struct {
+		key    string
+		people []Person
+	}{{ReviewedBy, reviewers}, {CoAuthoredBy, coAuthors}} {
+		for _, p := range l.people {
+			t := trailers.Trailer{Key: l.key, Value: p.String()}
+			k := strings.ToLower(t.Key + ": " + t.Value)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			add = append(add, t)
+		}
+	}
+	if len(add) == 0 {
+		return msg
+	}
+	msg = strings.TrimRight(msg, "\n")
+	switch {
+	case msg == "":
+	case len(existing) > 0:
+		// Extend the existing trailer block rather than starting a new one.
+		msg += "\n"
+	default:
+		msg += "\n\n"
+	}
+	return msg + add.String()
+}
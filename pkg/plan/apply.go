@@ -0,0 +1,281 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bretmckee/git-tools/pkg/git"
+	"github.com/bretmckee/git-tools/pkg/journal"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+)
+
+// applier executes the operations of a plan.
+type applier struct {
+	r repo.Repo
+	g *git.Git
+	// j records the pushes, which do not go through `r`.
+	j *journal.Journal
+	// created maps the placeholder numbers of planned pull requests to the
+	// numbers of the pull requests created for them.
+	created map[int]int
+}
+
+// number returns the number of the pull request `op` changes.
+func (a *applier) number(op Op) (int, error) {
+	if op.Number >= 0 {
+		return op.Number, nil
+	}
+	num, ok := a.created[op.Number]
+	if !ok {
+		return 0, fmt.Errorf("PR %d was not created", op.Number)
+	}
+	return num, nil
+}
+
+// openPR checks that pull request `num` is open and, if `sha` is not empty,
+// that its head is at `sha`. It returns true if the pull request is already
+// merged.
+func (a *applier) openPR(num int, sha string) (bool, error) {
+	pr, err := a.r.PullRequest(num)
+	if err != nil {
+		return false, err
+	}
+	if pr.GetMerged() {
+		return true, nil
+	}
+	if pr.GetState() != "open" {
+		return false, fmt.Errorf("PR %d is %s", num, pr.GetState())
+	}
+	if sha != "" && pr.GetHead().GetSHA() != sha {
+		return false, fmt.Errorf("head of PR %d is %s, not %s", num, pr.GetHead().GetSHA(), sha)
+	}
+	return false, nil
+}
+
+// check returns an error unless the state `op` was planned against still
+// holds. It returns true if the change has already been made.
+func (a *applier) check(op Op, num int) (bool, error) {
+	switch op.Kind {
+	case OpCreate:
+		if op.SHA == "" {
+			return false, nil
+		}
+		b, err := a.r.Branch(op.New.GetHead())
+		if err != nil {
+			return false, err
+		}
+		if sha := b.GetCommit().GetSHA(); sha != op.SHA {
+			return false, fmt.Errorf("branch %s is at %s, not %s", op.New.GetHead(), sha, op.SHA)
+		}
+		return false, nil
+	case OpChangeBase:
+		pr, err := a.r.PullRequest(num)
+		if err != nil {
+			return false, err
+		}
+		if pr.GetState() != "open" {
+			return false, fmt.Errorf("PR %d is %s", num, pr.GetState())
+		}
+		switch base := pr.GetBase().GetRef(); base {
+		case op.To:
+			return true, nil
+		case op.From:
+			return false, nil
+		default:
+			return false, fmt.Errorf("base of PR %d is %s, not %s", num, base, op.From)
+		}
+	case OpMerge:
+		return a.openPR(num, op.SHA)
+	case OpEnqueue, OpEnableAutoMerge:
+		merged, err := a.openPR(num, op.SHA)
+		if merged {
+			return false, fmt.Errorf("PR %d is merged", num)
+		}
+		return false, err
+	case OpMarkReady, OpConvertToDraft:
+		if _, err := a.openPR(num, ""); err != nil {
+			return false, err
+		}
+		pr, err := a.r.PullRequest(num)
+		if err != nil {
+			return false, err
+		}
+		return pr.GetDraft() == (op.Kind == OpConvertToDraft), nil
+	case OpEdit:
+		if op.Edit.GetState() == "closed" {
+			pr, err := a.r.PullRequest(num)
+			if err != nil {
+				return false, err
+			}
+			if pr.GetState() == "closed" && !pr.GetMerged() {
+				return true, nil
+			}
+		}
+		merged, err := a.openPR(num, "")
+		if merged {
+			return false, fmt.Errorf("PR %d is merged", num)
+		}
+		return false, err
+	case OpDisableAutoMerge, OpRequestReviewers, OpAddLabels, OpAddAssignees, OpAddComment:
+		merged, err := a.openPR(num, "")
+		if merged {
+			return false, fmt.Errorf("PR %d is merged", num)
+		}
+		return false, err
+	case OpDeleteBranch:
+		b, err := a.r.Branch(op.Ref)
+		if err != nil {
+			return false, err
+		}
+		if sha := b.GetCommit().GetSHA(); sha != op.SHA {
+			return false, fmt.Errorf("branch %s is at %s, not %s", op.Ref, sha, op.SHA)
+		}
+		return false, nil
+	case OpPush:
+		if a.g == nil {
+			return false, fmt.Errorf("a local clone is needed to push %s", op.Ref)
+		}
+		switch sha, err := a.g.RemoteSHA(op.Remote, op.Ref); {
+		case err != nil:
+			return false, err
+		case sha == op.To:
+			return true, nil
+		case sha != op.SHA:
+			return false, fmt.Errorf("%s on %s is at %s, not %s", op.Ref, op.Remote, sha, op.SHA)
+		}
+		return false, nil
+	case OpDeleteLocalBranch:
+		if a.g == nil {
+			return false, fmt.Errorf("a local clone is needed to delete %s", op.Ref)
+		}
+		sha, err := a.g.RevParse("refs/heads/" + op.Ref)
+		if err != nil {
+			// It is already gone.
+			return true, nil
+		}
+		if sha != op.SHA {
+			return false, fmt.Errorf("local branch %s is at %s, not %s", op.Ref, sha, op.SHA)
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown operation %q", op.Kind)
+	}
+}
+
+// execute makes the change described by `op`.
+func (a *applier) execute(op Op, num int) error {
+	switch op.Kind {
+	case OpCreate:
+		npr := *op.New
+		pr, err := a.r.CreatePullRequest(&npr)
+		if err != nil {
+			return err
+		}
+		a.created[op.Number] = pr.GetNumber()
+		glog.Infof("created PR %d for %s", pr.GetNumber(), npr.GetHead())
+		return nil
+	case OpChangeBase:
+		return a.r.ChangePullRequestBase(num, op.To)
+	case OpEdit:
+		_, err := a.r.EditPullRequest(num, op.Edit)
+		return err
+	case OpMerge:
+		_, err := a.r.MergePullRequest(num, op.SHA, op.Method, op.Message)
+		return err
+	case OpEnqueue:
+		return a.r.EnqueuePullRequest(num, op.SHA)
+	case OpEnableAutoMerge:
+		return a.r.EnableAutoMerge(num, op.SHA, op.Method, op.Message)
+	case OpDisableAutoMerge:
+		return a.r.DisableAutoMerge(num)
+	case OpMarkReady:
+		return a.r.MarkReadyForReview(num)
+	case OpConvertToDraft:
+		return a.r.ConvertToDraft(num)
+	case OpRequestReviewers:
+		return a.r.RequestReviewers(num, op.Names, op.Teams)
+	case OpAddLabels:
+		return a.r.AddLabels(num, op.Names)
+	case OpAddAssignees:
+		return a.r.AddAssignees(num, op.Names)
+	case OpAddComment:
+		return a.r.AddComment(num, op.Message)
+	case OpDeleteBranch:
+		return a.r.DeleteBranch(op.Ref)
+	case OpPush:
+		if err := a.g.Push(op.Remote, op.Ref, op.SHA, op.To); err != nil {
+			return err
+		}
+		if err := a.j.Record(journal.Entry{Op: journal.OpPush, Number: num, Ref: op.Ref, Before: op.SHA, After: op.To}); err != nil {
+			glog.Warningf("failed to journal push of %s: %v", op.Ref, err)
+		}
+		return nil
+	case OpDeleteLocalBranch:
+		return a.g.DeleteBranch(op.Ref)
+	}
+	return fmt.Errorf("unknown operation %q", op.Kind)
+}
+
+// Apply executes plan `p` against `r`, using the local clone `g` (which may be
+// nil if the plan has no git operations) and recording its pushes in `j`
+// (which may be nil). Before making any change it checks that the state every
+// operation on an existing pull request or branch was planned against still
+// holds, and it checks each operation again just before making it.
+//
+// Apply starts at operation p.Applied and updates p.Applied and p.Created as
+// it goes, so a plan which failed part way, saved after the failure, can be
+// applied again without repeating the operations which were made. Operations
+// whose change someone else has made in the meantime are skipped. With
+// `dryRun` only the checks are made.
+func Apply(r repo.Repo, g *git.Git, j *journal.Journal, p *Plan, dryRun bool) error {
+	if p.Created == nil {
+		p.Created = make(map[int]int)
+	}
+	if p.Applied >= len(p.Ops) {
+		glog.Infof("the %d operations of the plan have already been applied", len(p.Ops))
+		return nil
+	}
+	a := &applier{r: r, g: g, j: j, created: p.Created}
+	var stale []string
+	for i := p.Applied; i < len(p.Ops); i++ {
+		op := p.Ops[i]
+		num, err := a.number(op)
+		if err != nil && op.Kind != OpCreate {
+			// The pull request does not exist yet.
+			continue
+		}
+		if _, err := a.check(op, num); err != nil {
+			stale = append(stale, fmt.Sprintf("%d (%v): %v", i+1, op, err))
+		}
+	}
+	if len(stale) > 0 {
+		return fmt.Errorf("the plan is out of date:\n%s", strings.Join(stale, "\n"))
+	}
+	if dryRun {
+		glog.Infof("dryrun: the %d remaining operations of the plan can be applied", len(p.Ops)-p.Applied)
+		return nil
+	}
+
+	for i := p.Applied; i < len(p.Ops); i++ {
+		op := p.Ops[i]
+		num, err := a.number(op)
+		if err != nil && op.Kind != OpCreate {
+			return fmt.Errorf("operation %d (%v): %v", i+1, op, err)
+		}
+		done, err := a.check(op, num)
+		if err != nil {
+			return fmt.Errorf("operation %d (%v) is out of date: %v", i+1, op, err)
+		}
+		if done {
+			glog.Infof("operation %d (%v) was already applied", i+1, op)
+		} else {
+			glog.Infof("applying operation %d (%v)", i+1, op)
+			if err := a.execute(op, num); err != nil {
+				return fmt.Errorf("operation %d (%v) failed: %v", i+1, op, err)
+			}
+		}
+		p.Applied = i + 1
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Package plan describes the changes a command intends to make to a
+// repository, so that they can be reviewed, saved as JSON, and applied later.
+//
+// A command computes a plan by running against a Recorder instead of the
+// repository. Apply executes a plan after checking that the state each
+// operation was planned against still holds.
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// Kinds of operation.
+const (
+	OpCreate            = "create"
+	OpChangeBase        = "change-base"
+	OpEdit              = "edit"
+	OpMerge             = "merge"
+	OpEnqueue           = "enqueue"
+	OpEnableAutoMerge   = "enable-auto-merge"
+	OpDisableAutoMerge  = "disable-auto-merge"
+	OpMarkReady         = "mark-ready"
+	OpConvertToDraft    = "convert-to-draft"
+	OpRequestReviewers  = "request-reviewers"
+	OpAddLabels         = "add-labels"
+	OpAddAssignees      = "add-assignees"
+	OpAddComment        = "add-comment"
+	OpDeleteBranch      = "delete-branch"
+	OpPush              = "push"
+	OpDeleteLocalBranch = "delete-local-branch"
+)
+
+// Op is a single intended change.
+type Op struct {
+	Kind string `json:"kind"`
+	// Number is the pull request to change. A negative number refers to the
+	// pull request created by an earlier OpCreate in the same plan.
+	Number int `json:"number,omitempty"`
+	// Ref is the branch deleted or pushed.
+	Ref string `json:"ref,omitempty"`
+
+	// From is the base the pull request must still have (OpChangeBase).
+	From string `json:"from,omitempty"`
+	// SHA is the commit the pull request head or branch must still be at.
+	SHA string `json:"sha,omitempty"`
+
+	// To is the new base (OpChangeBase) or the new SHA of the branch
+	// (OpPush).
+	To string `json:"to,omitempty"`
+	// Remote is the git remote to push to (OpPush).
+	Remote  string                 `json:"remote,omitempty"`
+	New     *github.NewPullRequest `json:"new,omitempty"`
+	Edit    *github.PullRequest    `json:"edit,omitempty"`
+	Method  string                 `json:"method,omitempty"`
+	Message string                 `json:"message,omitempty"`
+	Names   []string               `json:"names,omitempty"`
+	Teams   []string               `json:"teams,omitempty"`
+}
+
+func (o Op) String() string {
+	switch o.Kind {
+	case OpCreate:
+		return fmt.Sprintf("%s PR %d for %s based on %s", o.Kind, o.Number, o.New.GetHead(), o.New.GetBase())
+	case OpChangeBase:
+		return fmt.Sprintf("%s of PR %d from %s to %s", o.Kind, o.Number, o.From, o.To)
+	case OpPush:
+		return fmt.Sprintf("%s %s to %s from %s to %s", o.Kind, o.Ref, o.Remote, o.SHA, o.To)
+	case OpDeleteBranch, OpDeleteLocalBranch:
+		return fmt.Sprintf("%s %s", o.Kind, o.Ref)
+	default:
+		return fmt.Sprintf("%s PR %d", o.Kind, o.Number)
+	}
+}
+
+// Plan is the list of changes a command intends to make to repository
+// Owner/Repo.
+type Plan struct {
+	Owner   string `json:"owner"`
+	Repo    string `json:"repo"`
+	Command string `json:"command"`
+	Ops     []Op   `json:"ops"`
+
+	// Applied is the number of operations Apply has made, and Created maps
+	// the placeholder numbers of the pull requests it created to their
+	// numbers, so that a plan which failed part way can be applied again.
+	Applied int         `json:"applied,omitempty"`
+	Created map[int]int `json:"created,omitempty"`
+}
+
+// Add appends `op` to the plan.
+func (p *Plan) Add(op Op) {
+	p.Ops = append(p.Ops, op)
+}
+
+// Save writes the plan as JSON to the file at `path`, or to standard output if
+// `path` is "-".
+func (p *Plan) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %v", err)
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write plan: %v", err)
+	}
+	return nil
+}
+
+// Print writes the operations of the plan to `w`, one per line.
+func (p *Plan) Print(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%s plan for %s/%s\n", p.Command, p.Owner, p.Repo); err != nil {
+		return err
+	}
+	for i, op := range p.Ops {
+		if _, err := fmt.Fprintf(w, "%d\t%v\n", i+1, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Report saves the plan as Save does if `path` is not empty, and otherwise
+// prints it to standard output. Commands use it for both -plan and -dry-run.
+func (p *Plan) Report(path string) error {
+	if path != "" {
+		return p.Save(path)
+	}
+	return p.Print(os.Stdout)
+}
+
+// Load reads a plan saved by Save from the file at `path`, or from standard
+// input if `path` is "-".
+func Load(path string) (*Plan, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %v", err)
+	}
+	p := &Plan{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %q: %v", path, err)
+	}
+	return p, nil
+}
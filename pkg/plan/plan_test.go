@@ -0,0 +1,230 @@
+package plan
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/google/go-github/v28/github"
+)
+
+// fakeRepo keeps pull requests, branches, labels and comments in memory and
+// applies the changes Apply makes to them, numbering created pull requests
+// after the existing ones.
+type fakeRepo struct {
+	repo.Repo
+	prs      map[int]*github.PullRequest
+	branches map[string]string
+	labels   map[int][]string
+	comments map[int][]string
+	// failLabels makes AddLabels fail.
+	failLabels bool
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		prs: map[int]*github.PullRequest{
+			1: {
+				Number: github.Int(1),
+				State:  github.String("open"),
+				Head:   &github.PullRequestBranch{Ref: github.String("a"), SHA: github.String("a1")},
+				Base:   &github.PullRequestBranch{Ref: github.String("old")},
+			},
+		},
+		branches: map[string]string{"a": "a1", "b": "b1"},
+		labels:   make(map[int][]string),
+		comments: make(map[int][]string),
+	}
+}
+
+func (f *fakeRepo) PullRequest(num int) (*github.PullRequest, error) {
+	pr := *f.prs[num]
+	base := *pr.Base
+	pr.Base = &base
+	return &pr, nil
+}
+
+func (f *fakeRepo) Branch(name string) (*github.Branch, error) {
+	return &github.Branch{Name: github.String(name), Commit: &github.RepositoryCommit{SHA: github.String(f.branches[name])}}, nil
+}
+
+func (f *fakeRepo) CreatePullRequest(npr *github.NewPullRequest) (*github.PullRequest, error) {
+	num := len(f.prs) + 1
+	f.prs[num] = &github.PullRequest{
+		Number: github.Int(num),
+		State:  github.String("open"),
+		Head:   &github.PullRequestBranch{Ref: npr.Head, SHA: github.String(f.branches[npr.GetHead()])},
+		Base:   &github.PullRequestBranch{Ref: npr.Base},
+	}
+	return f.prs[num], nil
+}
+
+func (f *fakeRepo) ChangePullRequestBase(num int, ref string) error {
+	f.prs[num].Base.Ref = github.String(ref)
+	return nil
+}
+
+func (f *fakeRepo) AddLabels(num int, labels []string) error {
+	if f.failLabels {
+		return errors.New("labels are unavailable")
+	}
+	f.labels[num] = append(f.labels[num], labels...)
+	return nil
+}
+
+func (f *fakeRepo) AddComment(num int, body string) error {
+	f.comments[num] = append(f.comments[num], body)
+	return nil
+}
+
+// plan records a plan which retargets PR 1, creates a PR for branch b and
+// labels it.
+func plan(t *testing.T, f *fakeRepo) *Plan {
+	t.Helper()
+	rec := NewRecorder(f, "owner", "repo", "test")
+	if err := rec.ChangePullRequestBase(1, "master"); err != nil {
+		t.Fatal(err)
+	}
+	pr, err := rec.CreatePullRequest(&github.NewPullRequest{Head: github.String("b"), Base: github.String("a")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.AddLabels(pr.GetNumber(), []string{"db"}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := rec.Plan.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(p, rec.Plan) {
+		t.Fatalf("Load() = %+v, want %+v", p, rec.Plan)
+	}
+	return p
+}
+
+func TestApply(t *testing.T) {
+	f := newFakeRepo()
+	p := plan(t, f)
+	if len(f.prs) != 1 || f.prs[1].GetBase().GetRef() != "old" {
+		t.Fatalf("recording the plan changed the repo")
+	}
+	wantOps := []string{OpChangeBase, OpCreate, OpAddLabels}
+	var ops []string
+	for _, op := range p.Ops {
+		ops = append(ops, op.Kind)
+	}
+	if !reflect.DeepEqual(ops, wantOps) {
+		t.Fatalf("planned %v, want %v", ops, wantOps)
+	}
+
+	if err := Apply(f, nil, nil, p, false); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if base := f.prs[1].GetBase().GetRef(); base != "master" {
+		t.Errorf("PR 1 base = %s, want master", base)
+	}
+	if len(f.prs) != 2 || f.prs[2].GetHead().GetRef() != "b" {
+		t.Errorf("PR for b was not created")
+	}
+	if !reflect.DeepEqual(f.labels, map[int][]string{2: {"db"}}) {
+		t.Errorf("labels = %v, want PR 2 labeled db", f.labels)
+	}
+}
+
+func TestApplyStale(t *testing.T) {
+	f := newFakeRepo()
+	p := plan(t, f)
+	f.prs[1].Base.Ref = github.String("other")
+	f.branches["b"] = "b2"
+
+	err := Apply(f, nil, nil, p, false)
+	if err == nil {
+		t.Fatalf("Apply() of a stale plan succeeded")
+	}
+	for _, want := range []string{"base of PR 1 is other", "branch b is at b2"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Apply() error = %v, want it to mention %q", err, want)
+		}
+	}
+	if len(f.prs) != 1 || f.prs[1].GetBase().GetRef() != "other" {
+		t.Errorf("Apply() of a stale plan changed the repo")
+	}
+}
+
+func TestApplyTwice(t *testing.T) {
+	f := newFakeRepo()
+	p := &Plan{Ops: []Op{{Kind: OpChangeBase, Number: 1, From: "old", To: "master"}}}
+	for i := 0; i < 2; i++ {
+		if err := Apply(f, nil, nil, p, false); err != nil {
+			t.Fatalf("Apply() #%d error = %v", i+1, err)
+		}
+	}
+	if base := f.prs[1].GetBase().GetRef(); base != "master" {
+		t.Errorf("PR 1 base = %s, want master", base)
+	}
+}
+
+func TestApplyResume(t *testing.T) {
+	f := newFakeRepo()
+	p := &Plan{Ops: []Op{
+		{Kind: OpCreate, Number: -1, SHA: "b1", New: &github.NewPullRequest{Head: github.String("b"), Base: github.String("a")}},
+		{Kind: OpAddComment, Number: -1, Message: "stacked"},
+		{Kind: OpAddLabels, Number: -1, Names: []string{"db"}},
+	}}
+	f.failLabels = true
+	if err := Apply(f, nil, nil, p, false); err == nil {
+		t.Fatalf("Apply() with failing labels succeeded")
+	}
+	if p.Applied != 2 || !reflect.DeepEqual(p.Created, map[int]int{-1: 2}) {
+		t.Fatalf("Apply() progress = %d, %v, want 2, map[-1:2]", p.Applied, p.Created)
+	}
+
+	// The progress survives saving the plan.
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := p.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.failLabels = false
+	for i := 0; i < 2; i++ {
+		if err := Apply(f, nil, nil, p, false); err != nil {
+			t.Fatalf("Apply() #%d after the failure error = %v", i+1, err)
+		}
+	}
+	if len(f.prs) != 2 {
+		t.Errorf("Apply() created %d PRs, want 1", len(f.prs)-1)
+	}
+	if got := f.comments[2]; !reflect.DeepEqual(got, []string{"stacked"}) {
+		t.Errorf("Apply() commented %q on PR 2, want one comment", got)
+	}
+	if got := f.labels[2]; !reflect.DeepEqual(got, []string{"db"}) {
+		t.Errorf("Apply() labelled PR 2 %v, want [db]", got)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	p := &Plan{Owner: "o", Repo: "r", Command: "rebase-prs", Ops: []Op{
+		{Kind: OpChangeBase, Number: 1, From: "old", To: "master"},
+		{Kind: OpPush, Number: 1, Ref: "b", Remote: "origin", SHA: "b1", To: "b2"},
+	}}
+	var buf strings.Builder
+	if err := p.Print(&buf); err != nil {
+		t.Fatalf("Print() error = %v", err)
+	}
+	want := "rebase-prs plan for o/r\n" +
+		"1\tchange-base of PR 1 from old to master\n" +
+		"2\tpush b to origin from b1 to b2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Print() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// Recorder is a repo.Repo which adds the changes made through it to a plan
+// instead of making them. Reads go to the underlying repository, so they do
+// not reflect the planned changes; pull requests it creates are given negative
+// numbers.
+type Recorder struct {
+	repo.Repo
+	Plan    *Plan
+	created int
+}
+
+// NewRecorder returns a Recorder which plans changes to `r`, which is
+// repository `owner`/`name`, for `command`.
+func NewRecorder(r repo.Repo, owner, name, command string) *Recorder {
+	return &Recorder{
+		Repo: r,
+		Plan: &Plan{Owner: owner, Repo: name, Command: command},
+	}
+}
+
+func (r *Recorder) add(op Op) {
+	glog.Infof("planning %v", op)
+	r.Plan.Add(op)
+}
+
+// pullRequest returns pull request `num`, or a placeholder if it is one this
+// plan creates.
+func (r *Recorder) pullRequest(num int) (*github.PullRequest, error) {
+	if num < 0 {
+		return &github.PullRequest{Number: github.Int(num), State: github.String("open")}, nil
+	}
+	return r.Repo.PullRequest(num)
+}
+
+func (r *Recorder) CreatePullRequest(npr *github.NewPullRequest) (*github.PullRequest, error) {
+	r.created++
+	num := -r.created
+	op := Op{Kind: OpCreate, Number: num, New: npr}
+	// A head in a fork can only be checked in the fork.
+	if head := npr.GetHead(); !strings.Contains(head, ":") {
+		b, err := r.Repo.Branch(head)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get head of planned PR: %v", err)
+		}
+		op.SHA = b.GetCommit().GetSHA()
+	}
+	r.add(op)
+	return &github.PullRequest{
+		Number: github.Int(num),
+		State:  github.String("open"),
+		Title:  npr.Title,
+		Body:   npr.Body,
+		Draft:  npr.Draft,
+		Head:   &github.PullRequestBranch{Ref: npr.Head, SHA: github.String(op.SHA)},
+		Base:   &github.PullRequestBranch{Ref: npr.Base},
+	}, nil
+}
+
+func (r *Recorder) ChangePullRequestBase(num int, ref string) error {
+	pr, err := r.pullRequest(num)
+	if err != nil {
+		return err
+	}
+	r.add(Op{Kind: OpChangeBase, Number: num, From: pr.GetBase().GetRef(), To: ref})
+	return nil
+}
+
+func (r *Recorder) EditPullRequest(num int, edit *github.PullRequest) (*github.PullRequest, error) {
+	pr, err := r.pullRequest(num)
+	if err != nil {
+		return nil, err
+	}
+	r.add(Op{Kind: OpEdit, Number: num, Edit: edit})
+	res := *pr
+	if edit.Title != nil {
+		res.Title = edit.Title
+	}
+	if edit.Body != nil {
+		res.Body = edit.Body
+	}
+	if edit.State != nil {
+		res.State = edit.State
+	}
+	return &res, nil
+}
+
+func (r *Recorder) MergePullRequest(num int, sha, method, msg string) (*github.PullRequest, error) {
+	pr, err := r.pullRequest(num)
+	if err != nil {
+		return nil, err
+	}
+	r.add(Op{Kind: OpMerge, Number: num, SHA: sha, Method: method, Message: msg})
+	res := *pr
+	res.Merged = github.Bool(true)
+	res.State = github.String("closed")
+	return &res, nil
+}
+
+func (r *Recorder) EnqueuePullRequest(num int, sha string) error {
+	r.add(Op{Kind: OpEnqueue, Number: num, SHA: sha})
+	return nil
+}
+
+func (r *Recorder) EnableAutoMerge(num int, sha, method, msg string) error {
+	r.add(Op{Kind: OpEnableAutoMerge, Number: num, SHA: sha, Method: method, Message: msg})
+	return nil
+}
+
+func (r *Recorder) DisableAutoMerge(num int) error {
+	r.add(Op{Kind: OpDisableAutoMerge, Number: num})
+	return nil
+}
+
+func (r *Recorder) MarkReadyForReview(num int) error {
+	r.add(Op{Kind: OpMarkReady, Number: num})
+	return nil
+}
+
+func (r *Recorder) ConvertToDraft(num int) error {
+	r.add(Op{Kind: OpConvertToDraft, Number: num})
+	return nil
+}
+
+func (r *Recorder) RequestReviewers(num int, reviewers, teams []string) error {
+	r.add(Op{Kind: OpRequestReviewers, Number: num, Names: reviewers, Teams: teams})
+	return nil
+}
+
+func (r *Recorder) AddLabels(num int, labels []string) error {
+	r.add(Op{Kind: OpAddLabels, Number: num, Names: labels})
+	return nil
+}
+
+func (r *Recorder) AddAssignees(num int, assignees []string) error {
+	r.add(Op{Kind: OpAddAssignees, Number: num, Names: assignees})
+	return nil
+}
+
+func (r *Recorder) AddComment(num int, body string) error {
+	r.add(Op{Kind: OpAddComment, Number: num, Message: body})
+	return nil
+}
+
+func (r *Recorder) DeleteBranch(name string) error {
+	b, err := r.Repo.Branch(name)
+	if err != nil {
+		return err
+	}
+	r.add(Op{Kind: OpDeleteBranch, Ref: name, SHA: b.GetCommit().GetSHA()})
+	return nil
+}
@@ -0,0 +1,36 @@
+// Package backend creates the repo.Repo for a repository on the service its
+// configuration names.
+package backend
+
+import (
+	"fmt"
+
+	"github.com/bretmckee/git-tools/pkg/config"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/bretmckee/git-tools/pkg/repo/client"
+	"github.com/bretmckee/git-tools/pkg/repo/gitlab"
+)
+
+// Backends.
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// Create returns the client for repository `owner`/`name` on the backend
+// configured for it in `cfg`. `baseURL` and `uploadURL` are the GitHub URLs
+// from the command line; the URL in the configuration takes precedence.
+func Create(cfg *config.Config, baseURL, uploadURL, owner, name, login, token string) (repo.Repo, error) {
+	rc := cfg.Repo(owner, name)
+	switch rc.Backend {
+	case "", GitHub:
+		if rc.URL != "" {
+			baseURL, uploadURL = rc.URL, rc.URL
+		}
+		return client.Create(baseURL, uploadURL, owner, name, login, token)
+	case GitLab:
+		return gitlab.Create(rc.URL, owner, name, login, token)
+	default:
+		return nil, fmt.Errorf("unknown backend %q for %s/%s", rc.Backend, owner, name)
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+)
+
+const enableAutoMergeMutation = `mutation($id: ID!, $sha: GitObjectID, $method: PullRequestMergeMethod, $body: String) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, expectedHeadOid: $sha, mergeMethod: $method, commitBody: $body}) {
+    clientMutationId
+  }
+}`
+
+func (c *Client) EnableAutoMerge(num int, sha, method, msg string) error {
+	pr, err := c.PullRequest(num)
+	if err != nil {
+		return fmt.Errorf("Failed to get pr %d to enable auto-merge: %v", num, err)
+	}
+	vars := map[string]interface{}{
+		"id":     pr.GetNodeID(),
+		"sha":    sha,
+		"method": strings.ToUpper(method),
+	}
+	// A rebase does not create a commit, so it cannot have a message.
+	if method != "rebase" && msg != "" {
+		vars["body"] = msg
+	}
+	var res struct{}
+	if err := c.graphQL(enableAutoMergeMutation, vars, &res); err != nil {
+		return fmt.Errorf("Failed to enable auto-merge for pr %d: %v", num, err)
+	}
+	return nil
+}
+
+const disableAutoMergeMutation = `mutation($id: ID!) {
+  disablePullRequestAutoMerge(input: {pullRequestId: $id}) {
+    clientMutationId
+  }
+}`
+
+func (c *Client) DisableAutoMerge(num int) error {
+	pr, err := c.PullRequest(num)
+	if err != nil {
+		return fmt.Errorf("Failed to get pr %d to disable auto-merge: %v", num, err)
+	}
+	var res struct{}
+	if err := c.graphQL(disableAutoMergeMutation, map[string]interface{}{"id": pr.GetNodeID()}, &res); err != nil {
+		return fmt.Errorf("Failed to disable auto-merge for pr %d: %v", num, err)
+	}
+	return nil
+}
+
+const autoMergeQuery = `query($owner: String!, $repo: String!, $num: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $num) {
+      autoMergeRequest { enabledAt mergeMethod enabledBy { login } }
+    }
+  }
+}`
+
+func (c *Client) AutoMerge(num int) (*repo.AutoMergeStatus, error) {
+	var res struct {
+		Repository struct {
+			PullRequest struct {
+				AutoMergeRequest *struct {
+					EnabledAt   time.Time `json:"enabledAt"`
+					MergeMethod string    `json:"mergeMethod"`
+					EnabledBy   struct {
+						Login string `json:"login"`
+					} `json:"enabledBy"`
+				} `json:"autoMergeRequest"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	vars := map[string]interface{}{"owner": c.owner, "repo": c.repo, "num": num}
+	if err := c.graphQL(autoMergeQuery, vars, &res); err != nil {
+		return nil, fmt.Errorf("Failed to get auto-merge status of pr %d: %v", num, err)
+	}
+	s := &repo.AutoMergeStatus{}
+	if a := res.Repository.PullRequest.AutoMergeRequest; a != nil {
+		s.Enabled = true
+		s.Method = strings.ToLower(a.MergeMethod)
+		s.EnabledBy = a.EnabledBy.Login
+		s.EnabledAt = a.EnabledAt
+	}
+	return s, nil
+}
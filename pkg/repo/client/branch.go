@@ -2,6 +2,7 @@ package client
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/golang/glog"
 	"github.com/google/go-github/v28/github"
@@ -37,3 +38,31 @@ func (c *Client) Branch(name string) (*github.Branch, error) {
 	}
 	return b, nil
 }
+
+func (c *Client) BranchProtection(name string) (*github.Protection, error) {
+	p, resp, err := c.client.Repositories.GetBranchProtection(c.ctx, c.owner, c.repo, name)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			glog.V(2).Infof("branch %q is not protected", name)
+			return nil, nil
+		}
+		if resp != nil && resp.StatusCode == http.StatusForbidden {
+			// Reading the protection requires admin rights, so it is unknown
+			// rather than an error for other users.
+			glog.V(1).Infof("protection of branch %q cannot be read, treating it as unprotected: %v", name, err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get of protection for branch %q failed: %v", name, err)
+	}
+	if glog.V(3) {
+		glog.Infof("Protection of Branch %q: %# v\n", name, pretty.Formatter(*p))
+	}
+	return p, nil
+}
+
+func (c *Client) DeleteBranch(name string) error {
+	if _, err := c.client.Git.DeleteRef(c.ctx, c.owner, c.repo, "heads/"+name); err != nil {
+		return fmt.Errorf("delete of branch %q failed: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+	"github.com/kr/pretty"
+)
+
+func (c *Client) CheckRuns(ref string) ([]*github.CheckRun, error) {
+	var runs []*github.CheckRun
+	for thisPage, lastPage := 1, 1; thisPage <= lastPage; thisPage++ {
+		glog.V(2).Infof("loading check runs for %q page %d", ref, thisPage)
+		o := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{Page: thisPage}}
+		res, resp, err := c.client.Checks.ListCheckRunsForRef(c.ctx, c.owner, c.repo, ref, o)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list check runs for %q: %v", ref, err)
+		}
+		runs = append(runs, res.CheckRuns...)
+		lastPage = resp.LastPage
+	}
+	if glog.V(3) {
+		glog.Infof("check runs of %q: %# v\n", ref, pretty.Formatter(runs))
+	}
+	return runs, nil
+}
+
+func (c *Client) CheckSuites(ref string) ([]*github.CheckSuite, error) {
+	var suites []*github.CheckSuite
+	for thisPage, lastPage := 1, 1; thisPage <= lastPage; thisPage++ {
+		glog.V(2).Infof("loading check suites for %q page %d", ref, thisPage)
+		o := &github.ListCheckSuiteOptions{ListOptions: github.ListOptions{Page: thisPage}}
+		res, resp, err := c.client.Checks.ListCheckSuitesForRef(c.ctx, c.owner, c.repo, ref, o)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list check suites for %q: %v", ref, err)
+		}
+		suites = append(suites, res.CheckSuites...)
+		lastPage = resp.LastPage
+	}
+	if glog.V(3) {
+		glog.Infof("check suites of %q: %# v\n", ref, pretty.Formatter(suites))
+	}
+	return suites, nil
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"fmt"
+)
+
+const markReadyMutation = `mutation($id: ID!) {
+  markPullRequestReadyForReview(input: {pullRequestId: $id}) {
+    clientMutationId
+  }
+}`
+
+const convertToDraftMutation = `mutation($id: ID!) {
+  convertPullRequestToDraft(input: {pullRequestId: $id}) {
+    clientMutationId
+  }
+}`
+
+func (c *Client) MarkReadyForReview(num int) error {
+	pr, err := c.PullRequest(num)
+	if err != nil {
+		return fmt.Errorf("Failed to get pr %d to mark it ready for review: %v", num, err)
+	}
+	var res struct{}
+	if err := c.graphQL(markReadyMutation, map[string]interface{}{"id": pr.GetNodeID()}, &res); err != nil {
+		return fmt.Errorf("Failed to mark pr %d ready for review: %v", num, err)
+	}
+	return nil
+}
+
+func (c *Client) ConvertToDraft(num int) error {
+	pr, err := c.PullRequest(num)
+	if err != nil {
+		return fmt.Errorf("Failed to get pr %d to convert it to a draft: %v", num, err)
+	}
+	var res struct{}
+	if err := c.graphQL(convertToDraftMutation, map[string]interface{}{"id": pr.GetNodeID()}, &res); err != nil {
+		return fmt.Errorf("Failed to convert pr %d to a draft: %v", num, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphQLPath returns the GraphQL endpoint relative to the REST base URL. It
+// is /graphql on github.com and /api/graphql on GitHub Enterprise, whose REST
+// API is under /api/v3.
+func (c *Client) graphQLPath() string {
+	if strings.HasSuffix(c.client.BaseURL.Path, "/v3/") {
+		return "../graphql"
+	}
+	return "graphql"
+}
+
+// graphQL runs `query` with `vars` and decodes its data into `res`.
+func (c *Client) graphQL(query string, vars map[string]interface{}, res interface{}) error {
+	req, err := c.client.NewRequest("POST", c.graphQLPath(), &graphQLRequest{Query: query, Variables: vars})
+	if err != nil {
+		return fmt.Errorf("failed to create graphql request: %v", err)
+	}
+	var resp graphQLResponse
+	if _, err := c.client.Do(c.ctx, req, &resp); err != nil {
+		return fmt.Errorf("graphql request failed: %v", err)
+	}
+	if len(resp.Errors) > 0 {
+		var msgs []string
+		for _, e := range resp.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return fmt.Errorf("graphql request failed: %s", strings.Join(msgs, "; "))
+	}
+	glog.V(3).Infof("graphql response: %s", resp.Data)
+	if err := json.Unmarshal(resp.Data, res); err != nil {
+		return fmt.Errorf("failed to decode graphql response: %v", err)
+	}
+	return nil
+}
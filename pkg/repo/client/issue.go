@@ -0,0 +1,34 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+	"github.com/kr/pretty"
+)
+
+func (c *Client) AddLabels(num int, labels []string) error {
+	res, _, err := c.client.Issues.AddLabelsToIssue(c.ctx, c.owner, c.repo, num, labels)
+	if err != nil {
+		return fmt.Errorf("Failed to add labels to %d: %v", num, err)
+	}
+	if glog.V(3) {
+		glog.Infof("labels of %d: %# v\n", num, pretty.Formatter(res))
+	}
+	return nil
+}
+
+func (c *Client) AddAssignees(num int, assignees []string) error {
+	if _, _, err := c.client.Issues.AddAssignees(c.ctx, c.owner, c.repo, num, assignees); err != nil {
+		return fmt.Errorf("Failed to add assignees to %d: %v", num, err)
+	}
+	return nil
+}
+
+func (c *Client) AddComment(num int, body string) error {
+	if _, _, err := c.client.Issues.CreateComment(c.ctx, c.owner, c.repo, num, &github.IssueComment{Body: github.String(body)}); err != nil {
+		return fmt.Errorf("Failed to add comment to %d: %v", num, err)
+	}
+	return nil
+}
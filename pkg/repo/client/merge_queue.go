@@ -0,0 +1,96 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+)
+
+const mergeQueueQuery = `query($owner: String!, $repo: String!, $branch: String!) {
+  repository(owner: $owner, name: $repo) {
+    mergeQueue(branch: $branch) { id }
+  }
+}`
+
+func (c *Client) MergeQueueEnabled(branch string) (bool, error) {
+	var res struct {
+		Repository struct {
+			MergeQueue *struct {
+				ID string `json:"id"`
+			} `json:"mergeQueue"`
+		} `json:"repository"`
+	}
+	vars := map[string]interface{}{"owner": c.owner, "repo": c.repo, "branch": branch}
+	if err := c.graphQL(mergeQueueQuery, vars, &res); err != nil {
+		return false, fmt.Errorf("Failed to get merge queue for %q: %v", branch, err)
+	}
+	return res.Repository.MergeQueue != nil, nil
+}
+
+const enqueueMutation = `mutation($id: ID!, $sha: GitObjectID) {
+  enqueuePullRequest(input: {pullRequestId: $id, expectedHeadOid: $sha}) {
+    mergeQueueEntry { position }
+  }
+}`
+
+func (c *Client) EnqueuePullRequest(num int, sha string) error {
+	pr, err := c.PullRequest(num)
+	if err != nil {
+		return fmt.Errorf("Failed to get pr %d to enqueue: %v", num, err)
+	}
+	var res struct{}
+	vars := map[string]interface{}{"id": pr.GetNodeID(), "sha": sha}
+	if err := c.graphQL(enqueueMutation, vars, &res); err != nil {
+		return fmt.Errorf("Failed to enqueue pr %d: %v", num, err)
+	}
+	return nil
+}
+
+const mergeQueueStatusQuery = `query($owner: String!, $repo: String!, $num: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $num) {
+      merged
+      mergeQueueEntry { position state }
+      timelineItems(last: 1, itemTypes: [REMOVED_FROM_MERGE_QUEUE_EVENT]) {
+        nodes { ... on RemovedFromMergeQueueEvent { reason createdAt } }
+      }
+    }
+  }
+}`
+
+func (c *Client) MergeQueueStatus(num int) (*repo.MergeQueueStatus, error) {
+	var res struct {
+		Repository struct {
+			PullRequest struct {
+				Merged          bool `json:"merged"`
+				MergeQueueEntry *struct {
+					Position int    `json:"position"`
+					State    string `json:"state"`
+				} `json:"mergeQueueEntry"`
+				TimelineItems struct {
+					Nodes []struct {
+						Reason    string    `json:"reason"`
+						CreatedAt time.Time `json:"createdAt"`
+					} `json:"nodes"`
+				} `json:"timelineItems"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	vars := map[string]interface{}{"owner": c.owner, "repo": c.repo, "num": num}
+	if err := c.graphQL(mergeQueueStatusQuery, vars, &res); err != nil {
+		return nil, fmt.Errorf("Failed to get merge queue status of pr %d: %v", num, err)
+	}
+	pr := res.Repository.PullRequest
+	s := &repo.MergeQueueStatus{Merged: pr.Merged}
+	if e := pr.MergeQueueEntry; e != nil {
+		s.Queued = true
+		s.Position = e.Position
+		s.State = e.State
+	}
+	if n := pr.TimelineItems.Nodes; len(n) > 0 {
+		s.RemovedReason = n[0].Reason
+		s.RemovedAt = n[0].CreatedAt
+	}
+	return s, nil
+}
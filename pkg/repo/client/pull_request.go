@@ -9,14 +9,40 @@ import (
 )
 
 func (c *Client) PullRequests() ([]*github.PullRequest, error) {
-	o := &github.PullRequestListOptions{}
-	prs, _, err := c.client.PullRequests.List(c.ctx, c.owner, c.repo, o)
+	prs, err := c.listPullRequests(github.PullRequestListOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("Failed to list pull requests: %v", err)
 	}
 	return prs, nil
 }
 
+func (c *Client) ClosedPullRequests(branch string) ([]*github.PullRequest, error) {
+	prs, err := c.listPullRequests(github.PullRequestListOptions{
+		State: "closed",
+		Head:  c.owner + ":" + branch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list closed pull requests for %q: %v", branch, err)
+	}
+	return prs, nil
+}
+
+// listPullRequests returns every page of the pull requests matching `o`.
+func (c *Client) listPullRequests(o github.PullRequestListOptions) ([]*github.PullRequest, error) {
+	var prs []*github.PullRequest
+	for thisPage, lastPage := 1, 1; thisPage <= lastPage; thisPage++ {
+		glog.V(2).Infof("loading pull requests (state %q) page %d", o.State, thisPage)
+		o.ListOptions = github.ListOptions{Page: thisPage, PerPage: 100}
+		page, resp, err := c.client.PullRequests.List(c.ctx, c.owner, c.repo, &o)
+		if err != nil {
+			return nil, err
+		}
+		prs = append(prs, page...)
+		lastPage = resp.LastPage
+	}
+	return prs, nil
+}
+
 func (c *Client) PullRequest(num int) (*github.PullRequest, error) {
 	pr, _, err := c.client.PullRequests.Get(c.ctx, c.owner, c.repo, num)
 	if err != nil {
@@ -28,6 +54,24 @@ func (c *Client) PullRequest(num int) (*github.PullRequest, error) {
 	return pr, nil
 }
 
+func (c *Client) PullRequestCommits(num int) ([]*github.RepositoryCommit, error) {
+	var commits []*github.RepositoryCommit
+	for thisPage, lastPage := 1, 1; thisPage <= lastPage; thisPage++ {
+		glog.V(2).Infof("loading commits of %d page %d", num, thisPage)
+		o := &github.ListOptions{Page: thisPage, PerPage: 100}
+		page, resp, err := c.client.PullRequests.ListCommits(c.ctx, c.owner, c.repo, num, o)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list commits of pr %d: %v", num, err)
+		}
+		commits = append(commits, page...)
+		lastPage = resp.LastPage
+	}
+	if glog.V(3) {
+		glog.Infof("commits of PR %d: %# v\n", num, pretty.Formatter(commits))
+	}
+	return commits, nil
+}
+
 func (c *Client) MergePullRequest(num int, sha, method, msg string) (*github.PullRequest, error) {
 	o := &github.PullRequestOptions{
 		SHA:         sha,
@@ -70,3 +114,40 @@ func (c *Client) ChangePullRequestBase(num int, ref string) error {
 	}
 	return nil
 }
+
+func (c *Client) EditPullRequest(num int, pr *github.PullRequest) (*github.PullRequest, error) {
+	res, _, err := c.client.PullRequests.Edit(c.ctx, c.owner, c.repo, num, pr)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to edit pr %d: %v", num, err)
+	}
+	return res, nil
+}
+
+func (c *Client) Reviews(num int) ([]*github.PullRequestReview, error) {
+	var reviews []*github.PullRequestReview
+	for thisPage, lastPage := 1, 1; thisPage <= lastPage; thisPage++ {
+		glog.V(2).Infof("loading reviews of %d page %d", num, thisPage)
+		o := &github.ListOptions{Page: thisPage}
+		page, resp, err := c.client.PullRequests.ListReviews(c.ctx, c.owner, c.repo, num, o)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list reviews of pr %d: %v", num, err)
+		}
+		reviews = append(reviews, page...)
+		lastPage = resp.LastPage
+	}
+	if glog.V(3) {
+		glog.Infof("reviews of PR %d: %# v\n", num, pretty.Formatter(reviews))
+	}
+	return reviews, nil
+}
+
+func (c *Client) RequestReviewers(num int, reviewers, teams []string) error {
+	r := github.ReviewersRequest{
+		Reviewers:     reviewers,
+		TeamReviewers: teams,
+	}
+	if _, _, err := c.client.PullRequests.RequestReviewers(c.ctx, c.owner, c.repo, num, r); err != nil {
+		return fmt.Errorf("Failed to request reviewers for pr %d: %v", num, err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+	"github.com/kr/pretty"
+)
+
+func (c *Client) User(login string) (*github.User, error) {
+	u, _, err := c.client.Users.Get(c.ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("Get of user %q failed: %v", login, err)
+	}
+	if glog.V(3) {
+		glog.Infof("User %q: %# v\n", login, pretty.Formatter(*u))
+	}
+	return u, nil
+}
@@ -0,0 +1,70 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// branch is a branch as returned by the API.
+type branch struct {
+	Name      string `json:"name"`
+	Protected bool   `json:"protected"`
+	Commit    commit `json:"commit"`
+}
+
+func (b *branch) githubBranch() *github.Branch {
+	return &github.Branch{
+		Name:      github.String(b.Name),
+		Protected: github.Bool(b.Protected),
+		Commit:    &github.RepositoryCommit{SHA: github.String(b.Commit.ID), Commit: b.Commit.githubCommit()},
+	}
+}
+
+func (c *Client) Branches() ([]*github.Branch, error) {
+	branches, err := getAll[branch](c, c.projectPath("/repository/branches"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list branches: %v", err)
+	}
+	var res []*github.Branch
+	for i := range branches {
+		res = append(res, branches[i].githubBranch())
+	}
+	return res, nil
+}
+
+func (c *Client) Branch(name string) (*github.Branch, error) {
+	var b branch
+	if _, err := c.do(http.MethodGet, c.projectPath("/repository/branches/"+url.PathEscape(name)), nil, nil, &b); err != nil {
+		return nil, fmt.Errorf("get of branch %q failed: %v", name, err)
+	}
+	return b.githubBranch(), nil
+}
+
+// BranchProtection returns an empty protection for a protected branch, since
+// GitLab keeps required approvals and pipelines in the project settings
+// rather than with the branch.
+func (c *Client) BranchProtection(name string) (*github.Protection, error) {
+	_, err := c.do(http.MethodGet, c.projectPath("/protected_branches/"+url.PathEscape(name)), nil, nil, nil)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if isForbidden(err) {
+		glog.V(1).Infof("protection of branch %q cannot be read, treating it as unprotected: %v", name, err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get of protection for branch %q failed: %v", name, err)
+	}
+	return &github.Protection{}, nil
+}
+
+func (c *Client) DeleteBranch(name string) error {
+	if _, err := c.do(http.MethodDelete, c.projectPath("/repository/branches/"+url.PathEscape(name)), nil, nil, nil); err != nil {
+		return fmt.Errorf("delete of branch %q failed: %v", name, err)
+	}
+	return nil
+}
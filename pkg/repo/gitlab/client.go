@@ -0,0 +1,162 @@
+// Package gitlab implements repo.Repo for a GitLab project using the GitLab
+// REST API (v4). Merge requests are presented as pull requests, pipeline jobs
+// as check runs and approvals as reviews, so the rest of git-tools can treat
+// both services alike.
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+)
+
+// DefaultURL is the API URL of gitlab.com.
+const DefaultURL = "https://gitlab.com/api/v4"
+
+// perPage is the page size requested from list endpoints, which is the
+// largest GitLab allows.
+const perPage = 100
+
+type Client struct {
+	baseURL string
+	// project is the full path (owner/name, where the owner may include
+	// subgroups) of the project.
+	project string
+	login   string
+	token   string
+	client  *http.Client
+}
+
+var _ repo.Repo = (*Client)(nil)
+
+// Create returns a client for project `owner`/`name` on the GitLab server with
+// API URL `baseURL` (DefaultURL if it is empty), authenticating with personal
+// access token `token`.
+func Create(baseURL, owner, name, login, token string) (*Client, error) {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid GitLab URL %q: %v", baseURL, err)
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		project: owner + "/" + name,
+		login:   login,
+		token:   token,
+		client:  http.DefaultClient,
+	}, nil
+}
+
+// apiError is an error response from the API.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("GitLab returned %d: %s", e.Status, e.Message)
+}
+
+// isNotFound reports whether `err` is a 404 response.
+func isNotFound(err error) bool {
+	e, ok := err.(*apiError)
+	return ok && e.Status == http.StatusNotFound
+}
+
+// isForbidden reports whether `err` is a 403 response.
+func isForbidden(err error) bool {
+	e, ok := err.(*apiError)
+	return ok && e.Status == http.StatusForbidden
+}
+
+// projectPath returns the API path of `path` within the project.
+func (c *Client) projectPath(path string) string {
+	return "/projects/" + url.PathEscape(c.project) + path
+}
+
+// do sends a `method` request for API path `path` with query `query` and, if
+// it is not nil, JSON body `body`. The JSON response is decoded into `res`,
+// unless it is nil.
+func (c *Client) do(method, path string, query url.Values, body, res interface{}) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var rb io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %v", err)
+		}
+		rb = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, u, rb)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	glog.V(3).Infof("%s %s", method, u)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Message interface{} `json:"message"`
+			Error   string      `json:"error"`
+		}
+		msg := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &e) == nil {
+			if e.Message != nil {
+				msg = fmt.Sprint(e.Message)
+			} else if e.Error != "" {
+				msg = e.Error
+			}
+		}
+		return nil, &apiError{Status: resp.StatusCode, Message: msg}
+	}
+	if res != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, res); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %v", err)
+		}
+	}
+	return resp, nil
+}
+
+// getAll returns every item of list endpoint `path`, following the pages.
+func getAll[T any](c *Client, path string, query url.Values) ([]T, error) {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("per_page", strconv.Itoa(perPage))
+	var all []T
+	for page := "1"; page != ""; {
+		q.Set("page", page)
+		var items []T
+		resp, err := c.do(http.MethodGet, path, q, nil, &items)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return all, nil
+}
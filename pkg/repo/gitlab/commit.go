@@ -0,0 +1,136 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// commit is a commit as returned by the API.
+type commit struct {
+	ID           string     `json:"id"`
+	Message      string     `json:"message"`
+	AuthorName   string     `json:"author_name"`
+	AuthorEmail  string     `json:"author_email"`
+	AuthoredDate *time.Time `json:"authored_date"`
+	ParentIDs    []string   `json:"parent_ids"`
+}
+
+func (cm *commit) githubCommit() *github.Commit {
+	gc := &github.Commit{
+		SHA:     github.String(cm.ID),
+		Message: github.String(cm.Message),
+		Author: &github.CommitAuthor{
+			Name:  github.String(cm.AuthorName),
+			Email: github.String(cm.AuthorEmail),
+			Date:  cm.AuthoredDate,
+		},
+	}
+	for _, p := range cm.ParentIDs {
+		gc.Parents = append(gc.Parents, github.Commit{SHA: github.String(p)})
+	}
+	return gc
+}
+
+func (c *Client) Commit(sha string) (*github.Commit, error) {
+	var cm commit
+	if _, err := c.do(http.MethodGet, c.projectPath("/repository/commits/"+url.PathEscape(sha)), nil, nil, &cm); err != nil {
+		return nil, fmt.Errorf("Failed to get commit %s: %v", sha, err)
+	}
+	return cm.githubCommit(), nil
+}
+
+// CombinedStatus returns no statuses: GitLab reports the jobs of pipelines as
+// commit statuses too, so they are only returned once, as check runs.
+func (c *Client) CombinedStatus(ref string) (*github.CombinedStatus, error) {
+	return &github.CombinedStatus{SHA: github.String(ref)}, nil
+}
+
+// job is a pipeline job as returned by the API.
+type job struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	WebURL    string     `json:"web_url"`
+	StartedAt *time.Time `json:"started_at"`
+	Pipeline  struct {
+		ID int64 `json:"id"`
+	} `json:"pipeline"`
+}
+
+// checkStatus maps the status of a job to the status and conclusion of a check
+// run.
+func checkStatus(status string) (string, string) {
+	switch status {
+	case "success":
+		return "completed", "success"
+	case "failed":
+		return "completed", "failure"
+	case "canceled":
+		return "completed", "cancelled"
+	case "skipped", "manual":
+		// A manual job only runs when someone starts it, so waiting for it
+		// would block forever.
+		return "completed", "skipped"
+	case "running":
+		return "in_progress", ""
+	default:
+		// created, pending, preparing, scheduled and waiting_for_resource.
+		return "queued", ""
+	}
+}
+
+// pipeline is a pipeline as returned by the API.
+type pipeline struct {
+	ID     int64  `json:"id"`
+	SHA    string `json:"sha"`
+	Status string `json:"status"`
+}
+
+// CheckRuns returns the jobs of the latest pipeline for commit `ref` as check
+// runs.
+func (c *Client) CheckRuns(ref string) ([]*github.CheckRun, error) {
+	var pipelines []pipeline
+	q := url.Values{"sha": {ref}, "order_by": {"id"}, "sort": {"desc"}, "per_page": {"1"}}
+	if _, err := c.do(http.MethodGet, c.projectPath("/pipelines"), q, nil, &pipelines); err != nil {
+		return nil, fmt.Errorf("Failed to list pipelines for %s: %v", ref, err)
+	}
+	if len(pipelines) == 0 {
+		return nil, nil
+	}
+	p := pipelines[0]
+	jobs, err := getAll[job](c, c.projectPath("/pipelines/"+strconv.FormatInt(p.ID, 10)+"/jobs"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list jobs of pipeline %d: %v", p.ID, err)
+	}
+	var runs []*github.CheckRun
+	for _, j := range jobs {
+		status, conclusion := checkStatus(j.Status)
+		run := &github.CheckRun{
+			ID:         github.Int64(j.ID),
+			Name:       github.String(j.Name),
+			HeadSHA:    github.String(ref),
+			Status:     github.String(status),
+			HTMLURL:    github.String(j.WebURL),
+			CheckSuite: &github.CheckSuite{ID: github.Int64(p.ID)},
+		}
+		if conclusion != "" {
+			run.Conclusion = github.String(conclusion)
+		}
+		if j.StartedAt != nil {
+			run.StartedAt = &github.Timestamp{Time: *j.StartedAt}
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// CheckSuites returns no suites, since every job of a pipeline is reported
+// by CheckRuns as soon as the pipeline is created.
+func (c *Client) CheckSuites(ref string) ([]*github.CheckSuite, error) {
+	return nil, nil
+}
@@ -0,0 +1,396 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/ci"
+	"github.com/google/go-github/v28/github"
+)
+
+const project = "/projects/group%2Fsub%2Frepo"
+
+// server is a stand-in for the parts of the GitLab API the client uses, for
+// project group/sub/repo.
+type server struct {
+	t        *testing.T
+	mrs      map[int]map[string]interface{}
+	branches []map[string]interface{}
+	jobs     []map[string]interface{}
+	users    map[string]int
+	// merged holds the parameters of the last merge.
+	merged map[string]interface{}
+}
+
+func newServer(t *testing.T) (*server, *Client) {
+	mr := func(iid int, title, state, source, target string) map[string]interface{} {
+		return map[string]interface{}{
+			"id": 1000 + iid, "iid": iid, "title": title, "state": state,
+			"source_branch": source, "target_branch": target, "sha": source + "-sha",
+			"source_project_id": 7, "target_project_id": 7,
+			"author":      map[string]interface{}{"id": 1, "username": "me"},
+			"reviewers":   []interface{}{map[string]interface{}{"id": 3, "username": "carol"}},
+			"diff_refs":   map[string]interface{}{"base_sha": target + "-sha"},
+			"description": "body",
+		}
+	}
+	s := &server{
+		t: t,
+		mrs: map[int]map[string]interface{}{
+			1: mr(1, "Add a", "merged", "a", "main"),
+			2: mr(2, "Draft: Add b", "opened", "b", "a"),
+			3: mr(3, "Add c", "opened", "c", "b"),
+		},
+		branches: []map[string]interface{}{
+			{"name": "main", "protected": true, "commit": map[string]interface{}{"id": "main-sha", "parent_ids": []string{"p"}}},
+			{"name": "b", "commit": map[string]interface{}{"id": "b-sha"}},
+			{"name": "c", "commit": map[string]interface{}{"id": "c-sha"}},
+		},
+		jobs: []map[string]interface{}{
+			{"id": 1, "name": "build", "status": "success", "started_at": "2026-10-19T01:00:00Z"},
+			{"id": 2, "name": "test", "status": "running"},
+		},
+		users: map[string]int{"alice": 2, "carol": 3},
+	}
+	s.mrs[1]["squash_commit_sha"] = "squashed"
+	s.mrs[1]["merged_at"] = "2026-10-19T02:00:00Z"
+	s.mrs[2]["draft"] = true
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	c, err := Create(ts.URL+"/api/v4", "group/sub", "repo", "me", "token")
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	return s, c
+}
+
+func (s *server) reply(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.t.Errorf("failed to encode response: %v", err)
+	}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("PRIVATE-TOKEN") != "token" {
+		http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4")
+	var body map[string]interface{}
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			s.t.Errorf("%s %s: bad body: %v", r.Method, path, err)
+		}
+	}
+	q := r.URL.Query()
+	route := r.Method + " " + strings.TrimPrefix(path, project)
+	parts := strings.Split(route, "/")
+	mr := func() map[string]interface{} {
+		n, _ := strconv.Atoi(parts[2])
+		return s.mrs[n]
+	}
+	switch {
+	case route == "GET /merge_requests":
+		// Serve one merge request per page to exercise paging.
+		var matching []map[string]interface{}
+		for i := 1; i <= len(s.mrs); i++ {
+			m := s.mrs[i]
+			if m["state"] == q.Get("state") && (q.Get("source_branch") == "" || m["source_branch"] == q.Get("source_branch")) {
+				matching = append(matching, m)
+			}
+		}
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page < len(matching) {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		}
+		if page > len(matching) {
+			s.reply(w, []interface{}{})
+			return
+		}
+		s.reply(w, matching[page-1:page])
+	case strings.HasPrefix(route, "GET /merge_requests/") && len(parts) == 3:
+		if m := mr(); m != nil {
+			s.reply(w, m)
+			return
+		}
+		http.Error(w, `{"message":"404 Not found"}`, http.StatusNotFound)
+	case strings.HasPrefix(route, "PUT /merge_requests/") && len(parts) == 3:
+		m := mr()
+		for k, v := range body {
+			switch k {
+			case "state_event":
+				m["state"] = map[interface{}]string{"close": "closed", "reopen": "opened"}[v]
+			case "reviewer_ids":
+				var rs []interface{}
+				for _, id := range v.([]interface{}) {
+					rs = append(rs, map[string]interface{}{"id": id})
+				}
+				m["reviewers"] = rs
+			default:
+				m[k] = v
+			}
+		}
+		if title, ok := body["title"].(string); ok {
+			m["draft"] = strings.HasPrefix(title, "Draft:")
+		}
+		s.reply(w, m)
+	case strings.HasPrefix(route, "PUT /merge_requests/") && parts[3] == "merge":
+		m := mr()
+		s.merged = body
+		m["state"] = "merged"
+		s.reply(w, m)
+	case strings.HasPrefix(route, "GET /merge_requests/") && parts[3] == "approvals":
+		// The second approval is by a deleted account, which has no user.
+		s.reply(w, map[string]interface{}{"approved_by": []interface{}{
+			map[string]interface{}{"user": map[string]interface{}{"id": 2, "username": "alice"}},
+			map[string]interface{}{"user": nil},
+		}})
+	case strings.HasPrefix(route, "GET /merge_requests/") && parts[3] == "commits":
+		s.reply(w, []interface{}{
+			map[string]interface{}{"id": "new", "message": "second"},
+			map[string]interface{}{"id": "old", "message": "first", "author_name": "Me"},
+		})
+	case route == "GET /repository/branches":
+		s.reply(w, s.branches)
+	case strings.HasPrefix(route, "GET /repository/branches/"):
+		for _, b := range s.branches {
+			if b["name"] == parts[3] {
+				s.reply(w, b)
+				return
+			}
+		}
+		http.Error(w, `{"message":"404 Branch Not Found"}`, http.StatusNotFound)
+	case strings.HasPrefix(route, "GET /protected_branches/"):
+		if parts[2] == "main" {
+			s.reply(w, map[string]interface{}{"name": "main"})
+			return
+		}
+		if parts[2] == "c" {
+			http.Error(w, `{"message":"403 Forbidden"}`, http.StatusForbidden)
+			return
+		}
+		http.Error(w, `{"message":"404 Not found"}`, http.StatusNotFound)
+	case route == "GET /pipelines":
+		if q.Get("sha") != "c-sha" {
+			s.reply(w, []interface{}{})
+			return
+		}
+		s.reply(w, []interface{}{map[string]interface{}{"id": 9, "sha": "c-sha", "status": "running"}})
+	case route == "GET /pipelines/9/jobs":
+		s.reply(w, s.jobs)
+	case r.Method == http.MethodGet && path == "/users":
+		id, ok := s.users[q.Get("username")]
+		if !ok {
+			s.reply(w, []interface{}{})
+			return
+		}
+		s.reply(w, []interface{}{map[string]interface{}{"id": id, "username": q.Get("username")}})
+	default:
+		s.t.Errorf("unexpected request %s %s", r.Method, path)
+		http.Error(w, `{"message":"404 Not found"}`, http.StatusNotFound)
+	}
+}
+
+func numbers(prs []*github.PullRequest) []int {
+	var res []int
+	for _, pr := range prs {
+		res = append(res, pr.GetNumber())
+	}
+	return res
+}
+
+func TestPullRequests(t *testing.T) {
+	_, c := newServer(t)
+	prs, err := c.PullRequests()
+	if err != nil {
+		t.Fatalf("PullRequests() failed: %v", err)
+	}
+	if got, want := numbers(prs), []int{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("PullRequests() = %v, want %v", got, want)
+	}
+	pr := prs[0]
+	if !pr.GetDraft() || pr.GetState() != "open" || pr.GetHead().GetRef() != "b" || pr.GetBase().GetRef() != "a" {
+		t.Errorf("PullRequests()[0] = %+v, want open draft b onto a", pr)
+	}
+	if pr.GetHead().GetRepo().GetFullName() != pr.GetBase().GetRepo().GetFullName() {
+		t.Errorf("head repo %q != base repo %q", pr.GetHead().GetRepo().GetFullName(), pr.GetBase().GetRepo().GetFullName())
+	}
+
+	closed, err := c.ClosedPullRequests("a")
+	if err != nil {
+		t.Fatalf("ClosedPullRequests() failed: %v", err)
+	}
+	if len(closed) != 1 {
+		t.Fatalf("ClosedPullRequests() = %v, want [1]", numbers(closed))
+	}
+	merged := closed[0]
+	wantMerged := time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC)
+	if merged.GetState() != "closed" || !merged.GetMerged() || !merged.GetMergedAt().Equal(wantMerged) || merged.GetMergeCommitSHA() != "squashed" {
+		t.Errorf("ClosedPullRequests()[0] = %+v, want merged at %v as squashed", merged, wantMerged)
+	}
+	if !merged.GetClosedAt().Equal(wantMerged) {
+		t.Errorf("ClosedPullRequests()[0] closed at %v, want %v since it has no closed_at", merged.GetClosedAt(), wantMerged)
+	}
+
+	if _, err := c.PullRequest(99); err == nil {
+		t.Error("PullRequest(99) succeeded, want an error")
+	}
+}
+
+func TestPullRequestCommits(t *testing.T) {
+	_, c := newServer(t)
+	commits, err := c.PullRequestCommits(3)
+	if err != nil {
+		t.Fatalf("PullRequestCommits() failed: %v", err)
+	}
+	var got []string
+	for _, rc := range commits {
+		got = append(got, rc.GetSHA()+" "+rc.GetCommit().GetMessage())
+	}
+	if want := []string{"old first", "new second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PullRequestCommits() = %v, want %v", got, want)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	s, c := newServer(t)
+	if err := c.ChangePullRequestBase(3, "a"); err != nil {
+		t.Fatalf("ChangePullRequestBase() failed: %v", err)
+	}
+	if got := s.mrs[3]["target_branch"]; got != "a" {
+		t.Errorf("target branch = %v after ChangePullRequestBase(), want a", got)
+	}
+
+	if err := c.MarkReadyForReview(2); err != nil {
+		t.Fatalf("MarkReadyForReview() failed: %v", err)
+	}
+	if got := s.mrs[2]["title"]; got != "Add b" {
+		t.Errorf("title = %q after MarkReadyForReview(), want %q", got, "Add b")
+	}
+	if err := c.ConvertToDraft(3); err != nil {
+		t.Fatalf("ConvertToDraft() failed: %v", err)
+	}
+	pr, err := c.PullRequest(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pr.GetDraft() || pr.GetTitle() != "Draft: Add c" {
+		t.Errorf("PullRequest() = %q (draft %v) after ConvertToDraft(), want a draft", pr.GetTitle(), pr.GetDraft())
+	}
+
+	if _, err := c.EditPullRequest(3, &github.PullRequest{State: github.String("closed")}); err != nil {
+		t.Fatalf("EditPullRequest() failed: %v", err)
+	}
+	if got := s.mrs[3]["state"]; got != "closed" {
+		t.Errorf("state = %v after closing, want closed", got)
+	}
+
+	if err := c.RequestReviewers(2, []string{"alice", "carol"}, nil); err != nil {
+		t.Fatalf("RequestReviewers() failed: %v", err)
+	}
+	pr, err = c.PullRequest(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, u := range pr.RequestedReviewers {
+		ids = append(ids, u.GetID())
+	}
+	if want := []int64{3, 2}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("reviewers = %v after RequestReviewers(), want %v", ids, want)
+	}
+	if err := c.RequestReviewers(2, []string{"nobody"}, nil); err == nil {
+		t.Error("RequestReviewers() of an unknown user succeeded, want an error")
+	}
+}
+
+func TestMergePullRequest(t *testing.T) {
+	s, c := newServer(t)
+	if _, err := c.MergePullRequest(2, "b-sha", "rebase", "msg"); err == nil {
+		t.Error("MergePullRequest() with rebase succeeded, want an error")
+	}
+	pr, err := c.MergePullRequest(2, "b-sha", "squash", "msg")
+	if err != nil {
+		t.Fatalf("MergePullRequest() failed: %v", err)
+	}
+	if !pr.GetMerged() {
+		t.Errorf("MergePullRequest() = %+v, want it merged", pr)
+	}
+	want := map[string]interface{}{"sha": "b-sha", "squash": true, "squash_commit_message": "msg"}
+	if !reflect.DeepEqual(s.merged, want) {
+		t.Errorf("merge parameters = %v, want %v", s.merged, want)
+	}
+
+	reviews, err := c.Reviews(3)
+	if err != nil {
+		t.Fatalf("Reviews() failed: %v", err)
+	}
+	if len(reviews) != 1 || reviews[0].GetUser().GetLogin() != "alice" || reviews[0].GetState() != "APPROVED" {
+		t.Errorf("Reviews() = %+v, want an approval by alice", reviews)
+	}
+}
+
+func TestBranches(t *testing.T) {
+	_, c := newServer(t)
+	branches, err := c.Branches()
+	if err != nil {
+		t.Fatalf("Branches() failed: %v", err)
+	}
+	if len(branches) != 3 || !branches[0].GetProtected() || branches[1].GetCommit().GetSHA() != "b-sha" {
+		t.Errorf("Branches() = %+v, want main (protected), b and c", branches)
+	}
+	b, err := c.Branch("main")
+	if err != nil {
+		t.Fatalf("Branch() failed: %v", err)
+	}
+	if got := b.GetCommit().GetCommit().Parents; len(got) != 1 || got[0].GetSHA() != "p" {
+		t.Errorf("Branch().Commit.Parents = %v, want [p]", got)
+	}
+	if p, err := c.BranchProtection("main"); err != nil || p == nil {
+		t.Errorf("BranchProtection(main) = %v, %v, want a protection", p, err)
+	}
+	if p, err := c.BranchProtection("b"); err != nil || p != nil {
+		t.Errorf("BranchProtection(b) = %v, %v, want nil", p, err)
+	}
+	if p, err := c.BranchProtection("c"); err != nil || p != nil {
+		t.Errorf("BranchProtection(c) = %v, %v, want nil when forbidden", p, err)
+	}
+}
+
+func TestChecks(t *testing.T) {
+	s, c := newServer(t)
+	v, err := ci.Get(c, "c-sha")
+	if err != nil {
+		t.Fatalf("ci.Get() failed: %v", err)
+	}
+	if v.State != ci.Pending || len(v.Contexts) != 2 {
+		t.Errorf("ci.Get() = %+v, want 2 contexts, pending", v)
+	}
+	s.jobs[1]["status"] = "failed"
+	if v, err = ci.Get(c, "c-sha"); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Matching(ci.Failure); len(got) != 1 || got[0].Name != "test" {
+		t.Errorf("ci.Get() failures = %v, want test", got)
+	}
+	s.jobs[1]["status"] = "manual"
+	if v, err = ci.Get(c, "c-sha"); err != nil {
+		t.Fatal(err)
+	}
+	if v.State != ci.Success {
+		t.Errorf("ci.Get() with a manual job = %+v, want success", v)
+	}
+	if v, err = ci.Get(c, "b-sha"); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Contexts) != 0 {
+		t.Errorf("ci.Get() of a commit without pipelines = %+v, want no contexts", v)
+	}
+}
@@ -0,0 +1,449 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// draftPrefix marks a merge request as a draft.
+const draftPrefix = "Draft: "
+
+// draftRE matches the title prefixes GitLab recognises as marking a draft.
+var draftRE = regexp.MustCompile(`(?i)^\s*(\[draft\]|\(draft\)|draft:|draft\s+-)\s*`)
+
+// mergeRequest is a merge request as returned by the API.
+type mergeRequest struct {
+	ID                        int64      `json:"id"`
+	IID                       int        `json:"iid"`
+	SourceProjectID           int        `json:"source_project_id"`
+	TargetProjectID           int        `json:"target_project_id"`
+	Title                     string     `json:"title"`
+	Description               string     `json:"description"`
+	State                     string     `json:"state"`
+	SourceBranch              string     `json:"source_branch"`
+	TargetBranch              string     `json:"target_branch"`
+	SHA                       string     `json:"sha"`
+	MergeCommitSHA            string     `json:"merge_commit_sha"`
+	SquashCommitSHA           string     `json:"squash_commit_sha"`
+	Draft                     bool       `json:"draft"`
+	WorkInProgress            bool       `json:"work_in_progress"`
+	Squash                    bool       `json:"squash"`
+	MergeWhenPipelineSucceeds bool       `json:"merge_when_pipeline_succeeds"`
+	Author                    *user      `json:"author"`
+	MergeUser                 *user      `json:"merge_user"`
+	Assignees                 []*user    `json:"assignees"`
+	Reviewers                 []*user    `json:"reviewers"`
+	WebURL                    string     `json:"web_url"`
+	CreatedAt                 *time.Time `json:"created_at"`
+	UpdatedAt                 *time.Time `json:"updated_at"`
+	MergedAt                  *time.Time `json:"merged_at"`
+	ClosedAt                  *time.Time `json:"closed_at"`
+	DiffRefs                  struct {
+		BaseSHA string `json:"base_sha"`
+	} `json:"diff_refs"`
+}
+
+// pullRequest returns `mr` as a pull request. Merged merge requests are
+// closed pull requests with MergedAt set.
+func (c *Client) pullRequest(mr *mergeRequest) *github.PullRequest {
+	state := "open"
+	if mr.State != "opened" {
+		state = "closed"
+	}
+	mergeSHA := mr.MergeCommitSHA
+	if mergeSHA == "" {
+		mergeSHA = mr.SquashCommitSHA
+	}
+	// Only the project is known for the source of a merge request from a
+	// fork, which is enough to tell it is not in this project.
+	headRepo := c.project
+	if mr.SourceProjectID != mr.TargetProjectID {
+		headRepo = fmt.Sprintf("project/%d", mr.SourceProjectID)
+	}
+	pr := &github.PullRequest{
+		ID:        github.Int64(mr.ID),
+		Number:    github.Int(mr.IID),
+		State:     github.String(state),
+		Title:     github.String(mr.Title),
+		Body:      github.String(mr.Description),
+		Draft:     github.Bool(mr.Draft || mr.WorkInProgress),
+		Merged:    github.Bool(mr.State == "merged"),
+		HTMLURL:   github.String(mr.WebURL),
+		CreatedAt: mr.CreatedAt,
+		UpdatedAt: mr.UpdatedAt,
+		MergedAt:  mr.MergedAt,
+		ClosedAt:  mr.ClosedAt,
+		Head: &github.PullRequestBranch{
+			Ref:   github.String(mr.SourceBranch),
+			SHA:   github.String(mr.SHA),
+			Label: github.String(mr.SourceBranch),
+			Repo:  &github.Repository{FullName: github.String(headRepo)},
+		},
+		Base: &github.PullRequestBranch{
+			Ref:  github.String(mr.TargetBranch),
+			SHA:  github.String(mr.DiffRefs.BaseSHA),
+			Repo: &github.Repository{FullName: github.String(c.project)},
+		},
+	}
+	if mergeSHA != "" {
+		pr.MergeCommitSHA = github.String(mergeSHA)
+	}
+	if mr.State == "merged" && pr.MergedAt == nil {
+		// Merge requests merged before GitLab recorded merged_at.
+		pr.MergedAt = mr.UpdatedAt
+	}
+	if mr.State == "merged" && pr.ClosedAt == nil {
+		// GitLab only sets closed_at for merge requests closed without
+		// merging, but a merged pull request is closed when it is merged.
+		pr.ClosedAt = pr.MergedAt
+	}
+	if mr.Author != nil {
+		pr.User = mr.Author.githubUser()
+	}
+	for _, a := range mr.Assignees {
+		pr.Assignees = append(pr.Assignees, a.githubUser())
+	}
+	for _, r := range mr.Reviewers {
+		pr.RequestedReviewers = append(pr.RequestedReviewers, r.githubUser())
+	}
+	return pr
+}
+
+func (c *Client) mrPath(num int, path string) string {
+	return c.projectPath("/merge_requests/" + strconv.Itoa(num) + path)
+}
+
+func (c *Client) mergeRequest(num int) (*mergeRequest, error) {
+	var mr mergeRequest
+	if _, err := c.do(http.MethodGet, c.mrPath(num, ""), nil, nil, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// update changes the fields of merge request `num` in `fields`.
+func (c *Client) update(num int, fields map[string]interface{}) (*github.PullRequest, error) {
+	var mr mergeRequest
+	if _, err := c.do(http.MethodPut, c.mrPath(num, ""), nil, fields, &mr); err != nil {
+		return nil, err
+	}
+	return c.pullRequest(&mr), nil
+}
+
+func (c *Client) mergeRequests(query url.Values) ([]*github.PullRequest, error) {
+	mrs, err := getAll[mergeRequest](c, c.projectPath("/merge_requests"), query)
+	if err != nil {
+		return nil, err
+	}
+	var res []*github.PullRequest
+	for i := range mrs {
+		res = append(res, c.pullRequest(&mrs[i]))
+	}
+	return res, nil
+}
+
+func (c *Client) PullRequests() ([]*github.PullRequest, error) {
+	prs, err := c.mergeRequests(url.Values{"state": {"opened"}})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list merge requests: %v", err)
+	}
+	return prs, nil
+}
+
+func (c *Client) ClosedPullRequests(branch string) ([]*github.PullRequest, error) {
+	var res []*github.PullRequest
+	for _, state := range []string{"merged", "closed"} {
+		prs, err := c.mergeRequests(url.Values{"state": {state}, "source_branch": {branch}})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list %s merge requests for %s: %v", state, branch, err)
+		}
+		for _, pr := range prs {
+			if pr.GetHead().GetRepo().GetFullName() == c.project {
+				res = append(res, pr)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (c *Client) PullRequest(num int) (*github.PullRequest, error) {
+	mr, err := c.mergeRequest(num)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get merge request %d: %v", num, err)
+	}
+	return c.pullRequest(mr), nil
+}
+
+func (c *Client) PullRequestCommits(num int) ([]*github.RepositoryCommit, error) {
+	commits, err := getAll[commit](c, c.mrPath(num, "/commits"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list commits of merge request %d: %v", num, err)
+	}
+	// GitLab lists the newest commit first.
+	var res []*github.RepositoryCommit
+	for i := len(commits) - 1; i >= 0; i-- {
+		gc := commits[i].githubCommit()
+		res = append(res, &github.RepositoryCommit{SHA: gc.SHA, Commit: gc})
+	}
+	return res, nil
+}
+
+// mergeOptions returns the parameters which merge with `method` and message
+// `msg` at head `sha`. GitLab projects choose between merge commits and fast
+// forwards in their settings, so only squashing can be requested.
+func mergeOptions(sha, method, msg string) (map[string]interface{}, error) {
+	opts := map[string]interface{}{"sha": sha}
+	switch method {
+	case "squash":
+		opts["squash"] = true
+		if msg != "" {
+			opts["squash_commit_message"] = msg
+		}
+	case "merge":
+		opts["squash"] = false
+		if msg != "" {
+			opts["merge_commit_message"] = msg
+		}
+	default:
+		return nil, fmt.Errorf("merge method %q is not supported by GitLab; use the fast-forward merge method of the project instead", method)
+	}
+	return opts, nil
+}
+
+func (c *Client) MergePullRequest(num int, sha, method, msg string) (*github.PullRequest, error) {
+	opts, err := mergeOptions(sha, method, msg)
+	if err != nil {
+		return nil, err
+	}
+	var mr mergeRequest
+	if _, err := c.do(http.MethodPut, c.mrPath(num, "/merge"), nil, opts, &mr); err != nil {
+		return nil, fmt.Errorf("Failed to merge merge request %d: %v", num, err)
+	}
+	return c.pullRequest(&mr), nil
+}
+
+// GitLab merge trains are not supported, so merges are always direct.
+func (c *Client) MergeQueueEnabled(branch string) (bool, error) {
+	return false, nil
+}
+
+func (c *Client) EnqueuePullRequest(num int, sha string) error {
+	return fmt.Errorf("merge queues are not supported for GitLab")
+}
+
+func (c *Client) MergeQueueStatus(num int) (*repo.MergeQueueStatus, error) {
+	return nil, fmt.Errorf("merge queues are not supported for GitLab")
+}
+
+// EnableAutoMerge sets the merge request to merge when its pipeline succeeds.
+func (c *Client) EnableAutoMerge(num int, sha, method, msg string) error {
+	opts, err := mergeOptions(sha, method, msg)
+	if err != nil {
+		return err
+	}
+	opts["merge_when_pipeline_succeeds"] = true
+	if _, err := c.do(http.MethodPut, c.mrPath(num, "/merge"), nil, opts, nil); err != nil {
+		return fmt.Errorf("Failed to enable auto-merge for merge request %d: %v", num, err)
+	}
+	return nil
+}
+
+func (c *Client) DisableAutoMerge(num int) error {
+	if _, err := c.do(http.MethodPost, c.mrPath(num, "/cancel_merge_when_pipeline_succeeds"), nil, nil, nil); err != nil {
+		return fmt.Errorf("Failed to disable auto-merge for merge request %d: %v", num, err)
+	}
+	return nil
+}
+
+func (c *Client) AutoMerge(num int) (*repo.AutoMergeStatus, error) {
+	mr, err := c.mergeRequest(num)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get auto-merge status of merge request %d: %v", num, err)
+	}
+	if !mr.MergeWhenPipelineSucceeds {
+		return &repo.AutoMergeStatus{}, nil
+	}
+	st := &repo.AutoMergeStatus{Enabled: true, Method: "merge"}
+	if mr.Squash {
+		st.Method = "squash"
+	}
+	if mr.MergeUser != nil {
+		st.EnabledBy = mr.MergeUser.Username
+	}
+	return st, nil
+}
+
+// MarkReadyForReview removes the draft prefix from the title of merge request
+// `num`.
+func (c *Client) MarkReadyForReview(num int) error {
+	mr, err := c.mergeRequest(num)
+	if err != nil {
+		return fmt.Errorf("Failed to get merge request %d to mark it ready for review: %v", num, err)
+	}
+	if _, err := c.update(num, map[string]interface{}{"title": draftRE.ReplaceAllString(mr.Title, "")}); err != nil {
+		return fmt.Errorf("Failed to mark merge request %d ready for review: %v", num, err)
+	}
+	return nil
+}
+
+// ConvertToDraft adds the draft prefix to the title of merge request `num`.
+func (c *Client) ConvertToDraft(num int) error {
+	mr, err := c.mergeRequest(num)
+	if err != nil {
+		return fmt.Errorf("Failed to get merge request %d to convert it to a draft: %v", num, err)
+	}
+	if draftRE.MatchString(mr.Title) {
+		return nil
+	}
+	if _, err := c.update(num, map[string]interface{}{"title": draftPrefix + mr.Title}); err != nil {
+		return fmt.Errorf("Failed to convert merge request %d to a draft: %v", num, err)
+	}
+	return nil
+}
+
+func (c *Client) ChangePullRequestBase(num int, ref string) error {
+	if _, err := c.update(num, map[string]interface{}{"target_branch": ref}); err != nil {
+		return fmt.Errorf("Failed to change target branch of merge request %d: %v", num, err)
+	}
+	return nil
+}
+
+func (c *Client) EditPullRequest(num int, pr *github.PullRequest) (*github.PullRequest, error) {
+	fields := make(map[string]interface{})
+	if pr.Title != nil {
+		fields["title"] = pr.GetTitle()
+	}
+	if pr.Body != nil {
+		fields["description"] = pr.GetBody()
+	}
+	switch pr.GetState() {
+	case "open":
+		fields["state_event"] = "reopen"
+	case "closed":
+		fields["state_event"] = "close"
+	}
+	res, err := c.update(num, fields)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to edit merge request %d: %v", num, err)
+	}
+	return res, nil
+}
+
+func (c *Client) CreatePullRequest(npr *github.NewPullRequest) (*github.PullRequest, error) {
+	if strings.Contains(npr.GetHead(), ":") {
+		return nil, fmt.Errorf("merge requests from forks (%s) are not supported for GitLab", npr.GetHead())
+	}
+	title := npr.GetTitle()
+	if npr.GetDraft() && !draftRE.MatchString(title) {
+		title = draftPrefix + title
+	}
+	fields := map[string]interface{}{
+		"source_branch": npr.GetHead(),
+		"target_branch": npr.GetBase(),
+		"title":         title,
+		"description":   npr.GetBody(),
+	}
+	var mr mergeRequest
+	if _, err := c.do(http.MethodPost, c.projectPath("/merge_requests"), nil, fields, &mr); err != nil {
+		return nil, fmt.Errorf("Failed to create merge request for %s: %v", npr.GetHead(), err)
+	}
+	pr := c.pullRequest(&mr)
+	glog.V(1).Infof("created merge request %d: %s", pr.GetNumber(), pr.GetHTMLURL())
+	return pr, nil
+}
+
+// Reviews returns an approving review for each user who approved merge
+// request `num`. GitLab does not record requests for changes.
+func (c *Client) Reviews(num int) ([]*github.PullRequestReview, error) {
+	var res struct {
+		ApprovedBy []struct {
+			User *user `json:"user"`
+		} `json:"approved_by"`
+	}
+	if _, err := c.do(http.MethodGet, c.mrPath(num, "/approvals"), nil, nil, &res); err != nil {
+		return nil, fmt.Errorf("Failed to get approvals of merge request %d: %v", num, err)
+	}
+	var reviews []*github.PullRequestReview
+	for _, a := range res.ApprovedBy {
+		if a.User == nil {
+			// The approval of a deleted account.
+			continue
+		}
+		reviews = append(reviews, &github.PullRequestReview{
+			User:  a.User.githubUser(),
+			State: github.String("APPROVED"),
+		})
+	}
+	return reviews, nil
+}
+
+// addUsers adds the users with logins `logins` to the users of merge request
+// `num` in `field` (reviewer_ids or assignee_ids), which are `current`.
+func (c *Client) addUsers(num int, field string, current []*user, logins []string) error {
+	ids := make([]int, 0, len(current)+len(logins))
+	seen := make(map[int]bool)
+	for _, u := range current {
+		ids = append(ids, u.ID)
+		seen[u.ID] = true
+	}
+	for _, login := range logins {
+		u, err := c.user(login)
+		if err != nil {
+			return err
+		}
+		if !seen[u.ID] {
+			ids = append(ids, u.ID)
+			seen[u.ID] = true
+		}
+	}
+	_, err := c.update(num, map[string]interface{}{field: ids})
+	return err
+}
+
+func (c *Client) RequestReviewers(num int, reviewers, teams []string) error {
+	if len(teams) > 0 {
+		return fmt.Errorf("GitLab cannot request reviews from teams %v", teams)
+	}
+	mr, err := c.mergeRequest(num)
+	if err != nil {
+		return fmt.Errorf("Failed to get merge request %d to request reviewers: %v", num, err)
+	}
+	if err := c.addUsers(num, "reviewer_ids", mr.Reviewers, reviewers); err != nil {
+		return fmt.Errorf("Failed to request reviewers for %d: %v", num, err)
+	}
+	return nil
+}
+
+func (c *Client) AddLabels(num int, labels []string) error {
+	if _, err := c.update(num, map[string]interface{}{"add_labels": strings.Join(labels, ",")}); err != nil {
+		return fmt.Errorf("Failed to add labels to %d: %v", num, err)
+	}
+	return nil
+}
+
+func (c *Client) AddAssignees(num int, assignees []string) error {
+	mr, err := c.mergeRequest(num)
+	if err != nil {
+		return fmt.Errorf("Failed to get merge request %d to add assignees: %v", num, err)
+	}
+	if err := c.addUsers(num, "assignee_ids", mr.Assignees, assignees); err != nil {
+		return fmt.Errorf("Failed to add assignees to %d: %v", num, err)
+	}
+	return nil
+}
+
+func (c *Client) AddComment(num int, body string) error {
+	if _, err := c.do(http.MethodPost, c.mrPath(num, "/notes"), nil, map[string]interface{}{"body": body}, nil); err != nil {
+		return fmt.Errorf("Failed to add comment to %d: %v", num, err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// user is a user as returned by the API.
+type user struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	Name        string `json:"name"`
+	PublicEmail string `json:"public_email"`
+	WebURL      string `json:"web_url"`
+}
+
+func (u *user) githubUser() *github.User {
+	gu := &github.User{
+		ID:      github.Int64(int64(u.ID)),
+		Login:   github.String(u.Username),
+		Name:    github.String(u.Name),
+		HTMLURL: github.String(u.WebURL),
+	}
+	if u.PublicEmail != "" {
+		gu.Email = github.String(u.PublicEmail)
+	}
+	return gu
+}
+
+// user returns the user with username `login`.
+func (c *Client) user(login string) (*user, error) {
+	var users []*user
+	if _, err := c.do(http.MethodGet, "/users", url.Values{"username": {login}}, nil, &users); err != nil {
+		return nil, fmt.Errorf("Failed to get user %q: %v", login, err)
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("user %q does not exist", login)
+	}
+	return users[0], nil
+}
+
+func (c *Client) User(login string) (*github.User, error) {
+	u, err := c.user(login)
+	if err != nil {
+		return nil, err
+	}
+	return u.githubUser(), nil
+}
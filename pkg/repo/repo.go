@@ -1,9 +1,39 @@
 package repo
 
 import (
+	"time"
+
 	"github.com/google/go-github/v28/github"
 )
 
+// MergeQueueStatus describes the progress of a pull request through a merge
+// queue.
+type MergeQueueStatus struct {
+	// Queued is true while the pull request is in the merge queue, in which
+	// case Position is its 1-based position and State is the state of its
+	// entry (for example AWAITING_CHECKS or MERGEABLE).
+	Queued   bool
+	Position int
+	State    string
+	// Merged is true once the pull request has been merged.
+	Merged bool
+	// RemovedReason is why the pull request was last removed from the merge
+	// queue, at RemovedAt. It is empty if it has never been removed.
+	RemovedReason string
+	RemovedAt     time.Time
+}
+
+// AutoMergeStatus describes whether a pull request will be merged
+// automatically once its requirements are met.
+type AutoMergeStatus struct {
+	Enabled bool
+	// Method is the merge method (merge, rebase or squash), and EnabledBy and
+	// EnabledAt record who enabled auto-merge and when, if Enabled is true.
+	Method    string
+	EnabledBy string
+	EnabledAt time.Time
+}
+
 type Repo interface {
 	// Branches returns a slice which contains all the branches for the
 	// repository.  Note that not all fields in the individual elements may be
@@ -14,27 +44,104 @@ type Repo interface {
 	// Branch returns full information for branch `name`.
 	Branch(name string) (*github.Branch, error)
 
+	// BranchProtection returns the protection settings for branch `name`, or
+	// nil if the branch is not protected or its protection cannot be read by
+	// the user.
+	BranchProtection(name string) (*github.Protection, error)
+
+	// DeleteBranch deletes branch `name`.
+	DeleteBranch(name string) error
+
 	// PullRequests returns a slice which contains all the pull requests for the
 	// repository.  Note that not all fields in the individual elements may be
 	// filled it. If complete data is required for a pull request, PullRequest
 	// should be called.
 	PullRequests() ([]*github.PullRequest, error)
 
+	// ClosedPullRequests returns the closed (including merged) pull requests
+	// whose head is branch `branch` of this repository.
+	ClosedPullRequests(branch string) ([]*github.PullRequest, error)
+
 	// PullRequest returns full information for pull request `num`.
 	PullRequest(num int) (*github.PullRequest, error)
 
+	// PullRequestCommits returns every commit of pull request `num`, oldest
+	// first. GitHub lists at most 250 commits for a pull request.
+	PullRequestCommits(num int) ([]*github.RepositoryCommit, error)
+
 	// MergePullRequest
 	MergePullRequest(num int, sha, method, msg string) (*github.PullRequest, error)
 
+	// MergeQueueEnabled returns true if pull requests into branch `branch`
+	// must be merged through a merge queue.
+	MergeQueueEnabled(branch string) (bool, error)
+
+	// EnqueuePullRequest adds pull request `num`, whose head must be `sha`, to
+	// the merge queue of its base branch.
+	EnqueuePullRequest(num int, sha string) error
+
+	// MergeQueueStatus returns the merge queue status of pull request `num`.
+	MergeQueueStatus(num int) (*MergeQueueStatus, error)
+
+	// EnableAutoMerge arranges for pull request `num`, whose head must be
+	// `sha`, to be merged with `method` and message `msg` once its
+	// requirements are met.
+	EnableAutoMerge(num int, sha, method, msg string) error
+
+	// DisableAutoMerge cancels auto-merge for pull request `num`.
+	DisableAutoMerge(num int) error
+
+	// AutoMerge returns the auto-merge status of pull request `num`.
+	AutoMerge(num int) (*AutoMergeStatus, error)
+
+	// MarkReadyForReview changes draft pull request `num` to ready for
+	// review.
+	MarkReadyForReview(num int) error
+
+	// ConvertToDraft changes pull request `num` back to a draft.
+	ConvertToDraft(num int) error
+
 	//ChangePullRequestBase changes the base of pull request `num` to be `ref`.
 	ChangePullRequestBase(num int, ref string) error
 
+	// EditPullRequest updates pull request `num` with the title, body and
+	// state fields which are set in `pr`.
+	EditPullRequest(num int, pr *github.PullRequest) (*github.PullRequest, error)
+
 	// CreatePullRequest creates a new pull request.
 	CreatePullRequest(npr *github.NewPullRequest) (*github.PullRequest, error)
 
+	// Reviews returns all the reviews of pull request `num`, in the order they
+	// were submitted.
+	Reviews(num int) ([]*github.PullRequestReview, error)
+
+	// RequestReviewers requests reviews of pull request `num` from the users
+	// with logins `reviewers` and the teams with slugs `teams`.
+	RequestReviewers(num int, reviewers, teams []string) error
+
+	// AddLabels adds `labels` to pull request (or issue) `num`.
+	AddLabels(num int, labels []string) error
+
+	// AddAssignees assigns the users with logins `assignees` to pull request
+	// (or issue) `num`.
+	AddAssignees(num int, assignees []string) error
+
+	// AddComment adds a comment with body `body` to pull request (or issue)
+	// `num`.
+	AddComment(num int, body string) error
+
+	// User returns the public profile of the user with login `login`.
+	User(login string) (*github.User, error)
+
 	// Commit returns the full information for the commit with SHA `sha`.
 	Commit(sha string) (*github.Commit, error)
 
 	// Statuses returns the statues for commit ref.
 	CombinedStatus(ref string) (*github.CombinedStatus, error)
+
+	// CheckRuns returns the latest check run of each check for commit ref.
+	CheckRuns(ref string) ([]*github.CheckRun, error)
+
+	// CheckSuites returns the check suites for commit ref.
+	CheckSuites(ref string) ([]*github.CheckSuite, error)
 }
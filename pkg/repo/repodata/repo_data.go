@@ -2,9 +2,11 @@ package repodata
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/bretmckee/git-tools/pkg/config"
 	"github.com/bretmckee/git-tools/pkg/repo"
-	"github.com/bretmckee/git-tools/pkg/repo/client"
+	"github.com/bretmckee/git-tools/pkg/repo/backend"
 	"github.com/golang/glog"
 	"github.com/google/go-github/v28/github"
 	"github.com/kr/pretty"
@@ -12,18 +14,38 @@ import (
 
 type RepoData struct {
 	repo.Repo
+	// Heads is the repository which holds the head branches of the pull
+	// requests. It is Repo itself unless the branches are pushed to a fork.
+	Heads repo.Repo
+	// HeadOwner is the owner of Heads if it is a fork, and empty otherwise.
+	HeadOwner string
+	// headName is the full name (owner/repo) of Heads.
+	headName    string
 	BranchBySHA map[string][]*github.Branch
 	PrBySHA     map[string]*github.PullRequest
 	PrByNumber  map[int]*github.PullRequest
 }
 
-func Create(baseURL, uploadURL, sourceOwner, sourceRepo, login, token string) (*RepoData, error) {
-	c, err := client.Create(baseURL, uploadURL, sourceOwner, sourceRepo, login, token)
+// Create returns the data for the pull requests of sourceOwner/sourceRepo, on
+// the backend cfg selects for it. If headOwner is not empty, the head branches
+// are read from the fork headOwner/headRepo instead of from the source
+// repository.
+func Create(cfg *config.Config, baseURL, uploadURL, sourceOwner, sourceRepo, headOwner, headRepo, login, token string) (*RepoData, error) {
+	c, err := backend.Create(cfg, baseURL, uploadURL, sourceOwner, sourceRepo, login, token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %v", err)
 	}
 	r := &RepoData{
-		Repo: c,
+		Repo:     c,
+		Heads:    c,
+		headName: sourceOwner + "/" + sourceRepo,
+	}
+	if headOwner != "" && !(strings.EqualFold(headOwner, sourceOwner) && strings.EqualFold(headRepo, sourceRepo)) {
+		if r.Heads, err = backend.Create(cfg, baseURL, uploadURL, headOwner, headRepo, login, token); err != nil {
+			return nil, fmt.Errorf("failed to create fork client: %v", err)
+		}
+		r.HeadOwner = headOwner
+		r.headName = headOwner + "/" + headRepo
 	}
 	if err := r.LoadData(); err != nil {
 		return nil, fmt.Errorf("failed to load data: %v", err)
@@ -33,13 +55,27 @@ func Create(baseURL, uploadURL, sourceOwner, sourceRepo, login, token string) (*
 
 const MaxChainLength = 150
 
+// Fork reports whether the head branches are in a fork.
+func (r *RepoData) Fork() bool {
+	return r.HeadOwner != ""
+}
+
+// HeadRef returns the name to use as the head of a pull request for `branch`,
+// which is qualified with the owner of the fork if there is one.
+func (r *RepoData) HeadRef(branch string) string {
+	if r.Fork() {
+		return r.HeadOwner + ":" + branch
+	}
+	return branch
+}
+
 func (r *RepoData) CommitChain(pos, end string) ([]string, error) {
 	var chain []string
 
 	glog.V(2).Infof("GetCommitChain begins pos=%s end=%s", pos, end)
 	for pos != end && len(chain) < MaxChainLength {
 		glog.V(2).Infof("pos=%s", pos)
-		commit, err := r.Commit(pos)
+		commit, err := r.Heads.Commit(pos)
 		if err != nil {
 			return nil, fmt.Errorf("GetCommitChain failed to get commit: %v", err)
 		}
@@ -128,7 +164,7 @@ func buildChains(prs map[string]*github.PullRequest) ([]Chain, error) {
 //XX}
 
 func (r *RepoData) loadBranches() error {
-	branches, err := r.Branches()
+	branches, err := r.Heads.Branches()
 	if err != nil {
 		return fmt.Errorf("list branches failed: %v", err)
 	}
@@ -157,8 +193,14 @@ func (r *RepoData) loadPRs() error {
 			return fmt.Errorf("unable to fetch full PR %d (sha %s): %v", id, sha, err)
 		}
 		glog.V(2).Infof("adding pr %d: %# v", id, pretty.Formatter(fullPR))
-		r.PrBySHA[sha] = fullPR
 		r.PrByNumber[id] = fullPR
+		// Only pull requests from the repository holding the branches can be
+		// for one of them.
+		if !strings.EqualFold(fullPR.GetHead().GetRepo().GetFullName(), r.headName) {
+			glog.V(2).Infof("pr %d is from %s, not %s", id, fullPR.GetHead().GetRepo().GetFullName(), r.headName)
+			continue
+		}
+		r.PrBySHA[sha] = fullPR
 	}
 	return nil
 }
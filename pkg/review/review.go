@@ -0,0 +1,99 @@
+// Package review computes the review decision for a pull request from its
+// reviews, the same way GitHub does.
+package review
+
+import (
+	"sort"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// Review states reported by the GitHub API.
+const (
+	StateApproved         = "APPROVED"
+	StateChangesRequested = "CHANGES_REQUESTED"
+	StateCommented        = "COMMENTED"
+	StateDismissed        = "DISMISSED"
+	StatePending          = "PENDING"
+)
+
+// Decisions, named after GitHub's reviewDecision values.
+const (
+	Approved         = "APPROVED"
+	ChangesRequested = "CHANGES_REQUESTED"
+	ReviewRequired   = "REVIEW_REQUIRED"
+)
+
+// Result is the effective review state of a pull request.
+type Result struct {
+	// Decision is one of Approved, ChangesRequested or ReviewRequired.
+	Decision string
+	// Approvers are the logins of the reviewers whose latest review approves.
+	Approvers []string
+	// Blockers are the logins of the reviewers whose latest review requests
+	// changes.
+	Blockers []string
+	// Required is the number of approvals that were required.
+	Required int
+}
+
+// Decide computes the review decision from `reviews`, requiring at least
+// `required` approvals. Only the latest approving, change requesting or
+// dismissed review of each reviewer counts; comments do not replace an earlier
+// decision. Reviews by `author` are ignored.
+func Decide(reviews []*github.PullRequestReview, required int, author string) *Result {
+	sorted := make([]*github.PullRequestReview, len(reviews))
+	copy(sorted, reviews)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetSubmittedAt().Before(sorted[j].GetSubmittedAt())
+	})
+
+	latest := make(map[string]string)
+	var order []string
+	for _, r := range sorted {
+		login := r.GetUser().GetLogin()
+		if login == "" || login == author {
+			continue
+		}
+		switch s := r.GetState(); s {
+		case StateApproved, StateChangesRequested, StateDismissed:
+			if _, ok := latest[login]; !ok {
+				order = append(order, login)
+			}
+			latest[login] = s
+		}
+	}
+
+	res := &Result{Required: required}
+	for _, login := range order {
+		switch latest[login] {
+		case StateApproved:
+			res.Approvers = append(res.Approvers, login)
+		case StateChangesRequested:
+			res.Blockers = append(res.Blockers, login)
+		}
+	}
+	switch {
+	case len(res.Blockers) > 0:
+		res.Decision = ChangesRequested
+	case len(res.Approvers) >= required:
+		res.Decision = Approved
+	default:
+		res.Decision = ReviewRequired
+	}
+	return res
+}
+
+// Required returns the number of approvals needed to merge into a branch with
+// protection `p` (which may be nil): the larger of the branch protection count
+// and `configured`, and always at least one.
+func Required(configured int, p *github.Protection) int {
+	required := configured
+	if rr := p.GetRequiredPullRequestReviews(); rr != nil && rr.RequiredApprovingReviewCount > required {
+		required = rr.RequiredApprovingReviewCount
+	}
+	if required < 1 {
+		required = 1
+	}
+	return required
+}
@@ -0,0 +1,110 @@
+package review
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func newReview(login, state string, minute int) *github.PullRequestReview {
+	t := time.Date(2020, 1, 1, 0, minute, 0, 0, time.UTC)
+	return &github.PullRequestReview{
+		User:        &github.User{Login: github.String(login)},
+		State:       github.String(state),
+		SubmittedAt: &t,
+	}
+}
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name          string
+		reviews       []*github.PullRequestReview
+		required      int
+		wantDecision  string
+		wantApprovers []string
+		wantBlockers  []string
+	}{
+		{
+			name:         "no reviews",
+			required:     1,
+			wantDecision: ReviewRequired,
+		},
+		{
+			name:          "approved",
+			reviews:       []*github.PullRequestReview{newReview("alice", StateApproved, 1)},
+			required:      1,
+			wantDecision:  Approved,
+			wantApprovers: []string{"alice"},
+		},
+		{
+			name:          "not enough approvals",
+			reviews:       []*github.PullRequestReview{newReview("alice", StateApproved, 1)},
+			required:      2,
+			wantDecision:  ReviewRequired,
+			wantApprovers: []string{"alice"},
+		},
+		{
+			name: "changes requested blocks",
+			reviews: []*github.PullRequestReview{
+				newReview("alice", StateApproved, 1),
+				newReview("bob", StateChangesRequested, 2),
+			},
+			required:      1,
+			wantDecision:  ChangesRequested,
+			wantApprovers: []string{"alice"},
+			wantBlockers:  []string{"bob"},
+		},
+		{
+			name: "later approval replaces changes requested",
+			reviews: []*github.PullRequestReview{
+				newReview("bob", StateApproved, 3),
+				newReview("bob", StateChangesRequested, 2),
+			},
+			required:      1,
+			wantDecision:  Approved,
+			wantApprovers: []string{"bob"},
+		},
+		{
+			name: "comment does not replace approval",
+			reviews: []*github.PullRequestReview{
+				newReview("alice", StateApproved, 1),
+				newReview("alice", StateCommented, 2),
+			},
+			required:      1,
+			wantDecision:  Approved,
+			wantApprovers: []string{"alice"},
+		},
+		{
+			name: "dismissed approval",
+			reviews: []*github.PullRequestReview{
+				newReview("alice", StateApproved, 1),
+				newReview("alice", StateDismissed, 2),
+			},
+			required:     1,
+			wantDecision: ReviewRequired,
+		},
+		{
+			name:         "author ignored",
+			reviews:      []*github.PullRequestReview{newReview("me", StateApproved, 1)},
+			required:     1,
+			wantDecision: ReviewRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Decide(tt.reviews, tt.required, "me")
+			if got.Decision != tt.wantDecision {
+				t.Errorf("Decide() Decision = %s, want %s", got.Decision, tt.wantDecision)
+			}
+			if !reflect.DeepEqual(got.Approvers, tt.wantApprovers) {
+				t.Errorf("Decide() Approvers = %v, want %v", got.Approvers, tt.wantApprovers)
+			}
+			if !reflect.DeepEqual(got.Blockers, tt.wantBlockers) {
+				t.Errorf("Decide() Blockers = %v, want %v", got.Blockers, tt.wantBlockers)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package stack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+)
+
+// MergedBranch is a branch whose pull request was merged and which is no
+// longer used by any open pull request.
+type MergedBranch struct {
+	Name string
+	// Number is the merged pull request.
+	Number int
+	// SHA is the head of the pull request when it was merged.
+	SHA string
+	// Remote is true if the branch still exists in the repository.
+	Remote bool
+}
+
+// MergedBranches returns the branches, among those in the repository and
+// `local`, which start with `prefix`, belong to a merged pull request, are not
+// the head or base of any open pull request, and are not protected. They are
+// sorted by name.
+func MergedBranches(r repo.Repo, prefix string, local []string) ([]MergedBranch, error) {
+	if prefix == "" {
+		return nil, fmt.Errorf("a branch prefix is required")
+	}
+	prs, err := r.PullRequests()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get pull requests: %v", err)
+	}
+	inUse := make(map[string]int)
+	for _, pr := range prs {
+		inUse[pr.GetHead().GetRef()] = pr.GetNumber()
+		inUse[pr.GetBase().GetRef()] = pr.GetNumber()
+	}
+	branches, err := r.Branches()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get branches: %v", err)
+	}
+	remote := make(map[string]bool)
+	protected := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, b := range branches {
+		remote[b.GetName()] = true
+		protected[b.GetName()] = b.GetProtected()
+		names[b.GetName()] = true
+	}
+	for _, name := range local {
+		names[name] = true
+	}
+	var sorted []string
+	for name := range names {
+		if strings.HasPrefix(name, prefix) {
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+
+	var res []MergedBranch
+	for _, name := range sorted {
+		if protected[name] {
+			glog.V(1).Infof("branch %s is protected", name)
+			continue
+		}
+		if num, ok := inUse[name]; ok {
+			glog.V(1).Infof("branch %s is still used by open PR %d", name, num)
+			continue
+		}
+		closed, err := r.ClosedPullRequests(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get closed pull requests for %s: %v", name, err)
+		}
+		// The merged field is not filled in when listing pull requests, but
+		// merged_at is.
+		pr := latestClosed(closed)
+		if pr == nil || pr.GetMergedAt().IsZero() {
+			glog.V(1).Infof("branch %s does not belong to a merged PR", name)
+			continue
+		}
+		res = append(res, MergedBranch{
+			Name:   name,
+			Number: pr.GetNumber(),
+			SHA:    pr.GetHead().GetSHA(),
+			Remote: remote[name],
+		})
+	}
+	return res, nil
+}
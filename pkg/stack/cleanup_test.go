@@ -0,0 +1,44 @@
+package stack
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestMergedBranches(t *testing.T) {
+	f := &fakeRepo{
+		open: []*github.PullRequest{
+			newPR(10, "me/d", "me/c"),
+			newPR(11, "other", "me/base"),
+		},
+		closed: []*github.PullRequest{
+			closedPR(1, "me/a", "master", true),
+			closedPR(2, "me/b", "me/a", true),
+			closedPR(3, "me/c", "me/b", true),
+			closedPR(4, "me/abandoned", "master", false),
+			closedPR(5, "me/base", "master", true),
+			closedPR(6, "me/locked", "master", true),
+			closedPR(7, "you/x", "master", true),
+		},
+		branches:  []string{"master", "me/a", "me/c", "me/d", "me/abandoned", "me/base", "other", "you/x"},
+		protected: []string{"me/locked"},
+	}
+
+	got, err := MergedBranches(f, "me/", []string{"me/a", "me/b", "me/wip", "you/x"})
+	if err != nil {
+		t.Fatalf("MergedBranches() error = %v", err)
+	}
+	want := []MergedBranch{
+		{Name: "me/a", Number: 1, SHA: "me/a-sha", Remote: true},
+		{Name: "me/b", Number: 2, SHA: "me/b-sha", Remote: false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergedBranches() = %+v, want %+v", got, want)
+	}
+
+	if _, err := MergedBranches(f, "", nil); err == nil {
+		t.Errorf("MergedBranches() with no prefix succeeded, want error")
+	}
+}
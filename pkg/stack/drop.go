@@ -0,0 +1,46 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// Drop removes open pull request `pr` from its stack: the pull requests based
+// on its head are retargeted to its base, then it is closed with a comment
+// `comment` and, if `deleteBranch` is set, its head branch is deleted. The
+// children are retargeted first because GitHub closes the pull requests based
+// on a branch when it is deleted. It returns the numbers of the children.
+func Drop(r repo.Repo, pr *github.PullRequest, comment string, deleteBranch bool) ([]int, error) {
+	num := pr.GetNumber()
+	if pr.GetState() != "open" {
+		return nil, fmt.Errorf("PR %d is %s", num, State(pr))
+	}
+	children, err := Retarget(r, pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retarget children of PR %d: %v", num, err)
+	}
+	branch := pr.GetHead().GetRef()
+	if deleteBranch && !HeadInBase(pr) {
+		glog.Warningf("not deleting %s: it is in %s", branch, pr.GetHead().GetRepo().GetFullName())
+		deleteBranch = false
+	}
+	if comment != "" {
+		if err := r.AddComment(num, comment); err != nil {
+			return children, fmt.Errorf("failed to comment on PR %d: %v", num, err)
+		}
+	}
+	glog.Infof("closing PR %d", num)
+	if _, err := r.EditPullRequest(num, &github.PullRequest{State: github.String("closed")}); err != nil {
+		return children, fmt.Errorf("failed to close PR %d: %v", num, err)
+	}
+	if deleteBranch {
+		glog.Infof("deleting branch %s", branch)
+		if err := r.DeleteBranch(branch); err != nil {
+			return children, fmt.Errorf("failed to delete branch %s: %v", branch, err)
+		}
+	}
+	return children, nil
+}
@@ -0,0 +1,91 @@
+package stack
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestDrop(t *testing.T) {
+	open := func(num int, head, base string) *github.PullRequest {
+		pr := newPR(num, head, base)
+		pr.State = github.String("open")
+		return pr
+	}
+	fork := newForkPR(5, "b", "master")
+	fork.State = github.String("open")
+	closed := closedPR(2, "b", "a", false)
+	closed.State = github.String("closed")
+	tests := []struct {
+		name         string
+		pr           *github.PullRequest
+		deleteBranch bool
+		wantChildren []int
+		wantChanged  map[int]string
+		wantStates   map[int]string
+		wantDeleted  []string
+		wantErr      bool
+	}{
+		{
+			name:         "middle",
+			pr:           open(2, "b", "a"),
+			deleteBranch: true,
+			wantChildren: []int{3},
+			wantChanged:  map[int]string{3: "a"},
+			wantStates:   map[int]string{2: "closed"},
+			wantDeleted:  []string{"b"},
+		},
+		{
+			name:         "keep branch",
+			pr:           open(2, "b", "a"),
+			wantChildren: []int{3},
+			wantChanged:  map[int]string{3: "a"},
+			wantStates:   map[int]string{2: "closed"},
+		},
+		{
+			name:         "fork",
+			pr:           fork,
+			deleteBranch: true,
+			wantChanged:  map[int]string{},
+			wantStates:   map[int]string{5: "closed"},
+		},
+		{
+			name:    "closed",
+			pr:      closed,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeRepo{
+				open:     []*github.PullRequest{open(1, "a", "master"), tt.pr, open(3, "c", "b")},
+				changed:  make(map[int]string),
+				comments: make(map[int]string),
+				states:   make(map[int]string),
+			}
+			children, err := Drop(f, tt.pr, "abandoned", tt.deleteBranch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Drop() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(children, tt.wantChildren) {
+				t.Errorf("Drop() = %v, want %v", children, tt.wantChildren)
+			}
+			if !reflect.DeepEqual(f.changed, tt.wantChanged) {
+				t.Errorf("Drop() changed bases %v, want %v", f.changed, tt.wantChanged)
+			}
+			if !reflect.DeepEqual(f.states, tt.wantStates) {
+				t.Errorf("Drop() changed states %v, want %v", f.states, tt.wantStates)
+			}
+			if !reflect.DeepEqual(f.deleted, tt.wantDeleted) {
+				t.Errorf("Drop() deleted %v, want %v", f.deleted, tt.wantDeleted)
+			}
+			if _, commented := f.comments[tt.pr.GetNumber()]; !commented {
+				t.Errorf("Drop() did not comment on PR %d", tt.pr.GetNumber())
+			}
+		})
+	}
+}
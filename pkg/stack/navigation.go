@@ -0,0 +1,88 @@
+package stack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+const (
+	// NavigationBegin and NavigationEnd delimit the stack navigation section
+	// of a pull request body. Everything outside of them belongs to the
+	// author and is never modified.
+	NavigationBegin = "<!-- git-tools:stack:begin -->"
+	NavigationEnd   = "<!-- git-tools:stack:end -->"
+
+	currentMarker = "➡️"
+)
+
+// Navigation renders the navigation section for `current` within `stack`,
+// including the delimiters.
+func Navigation(stack []*github.PullRequest, current int) string {
+	var b strings.Builder
+	b.WriteString(NavigationBegin + "\n")
+	b.WriteString("**Stack** (bottom to top):\n")
+	for _, pr := range stack {
+		marker := ""
+		if pr.GetNumber() == current {
+			marker = currentMarker + " "
+		}
+		link := fmt.Sprintf("#%d", pr.GetNumber())
+		if u := pr.GetHTMLURL(); u != "" {
+			link = fmt.Sprintf("[#%d](%s)", pr.GetNumber(), u)
+		}
+		fmt.Fprintf(&b, "1. %s%s %s (%s)\n", marker, link, pr.GetTitle(), State(pr))
+	}
+	b.WriteString(NavigationEnd)
+	return b.String()
+}
+
+// ReplaceNavigation returns `body` with its navigation section replaced by
+// `section`. If `body` has no navigation section, `section` is appended.
+func ReplaceNavigation(body, section string) string {
+	begin := strings.Index(body, NavigationBegin)
+	end := strings.Index(body, NavigationEnd)
+	if begin >= 0 && end > begin {
+		return body[:begin] + section + body[end+len(NavigationEnd):]
+	}
+	body = strings.TrimRight(body, "\n")
+	if body == "" {
+		return section + "\n"
+	}
+	return body + "\n\n" + section + "\n"
+}
+
+// FindNavigation returns the navigation section of `body`, including the
+// delimiters, or "" if `body` does not have one.
+func FindNavigation(body string) string {
+	begin := strings.Index(body, NavigationBegin)
+	end := strings.Index(body, NavigationEnd)
+	if begin < 0 || end < begin {
+		return ""
+	}
+	return body[begin : end+len(NavigationEnd)]
+}
+
+// UpdateNavigation rewrites the navigation section of every pull request in
+// `stack` which is out of date.
+func UpdateNavigation(r repo.Repo, stack []*github.PullRequest) error {
+	for _, pr := range stack {
+		if pr.GetMerged() || pr.GetState() == "closed" {
+			continue
+		}
+		body := ReplaceNavigation(pr.GetBody(), Navigation(stack, pr.GetNumber()))
+		if body == pr.GetBody() {
+			glog.V(2).Infof("navigation for PR %d is up to date", pr.GetNumber())
+			continue
+		}
+		glog.Infof("updating navigation for PR %d", pr.GetNumber())
+		if _, err := r.EditPullRequest(pr.GetNumber(), &github.PullRequest{Body: github.String(body)}); err != nil {
+			return fmt.Errorf("failed to update navigation for PR %d: %v", pr.GetNumber(), err)
+		}
+		pr.Body = github.String(body)
+	}
+	return nil
+}
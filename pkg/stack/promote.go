@@ -0,0 +1,51 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/bretmckee/git-tools/pkg/ci"
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// Promote marks the draft pull requests in `prs` ready for review once they
+// are the bottom of their stack or, if `onGreen` is set, once all of their
+// checks pass. Only pull requests opened by `author` are considered, unless it
+// is empty. It returns the numbers of the pull requests which were marked
+// ready.
+func Promote(r repo.Repo, prs []*github.PullRequest, author string, onGreen bool) ([]int, error) {
+	bottom := make(map[int]bool)
+	for _, pr := range Bottoms(prs) {
+		bottom[pr.GetNumber()] = true
+	}
+	var promoted []int
+	for _, pr := range prs {
+		n := pr.GetNumber()
+		if !pr.GetDraft() || (author != "" && pr.GetUser().GetLogin() != author) {
+			continue
+		}
+		reason := "it is the bottom of its stack"
+		if !bottom[n] {
+			if !onGreen {
+				continue
+			}
+			v, err := ci.Get(r, pr.GetHead().GetSHA())
+			if err != nil {
+				return nil, fmt.Errorf("failed to get checks of PR %d: %v", n, err)
+			}
+			// A commit without any checks has not been tested at all.
+			if v.State != ci.Success || len(v.Contexts) == 0 {
+				glog.V(1).Infof("PR %d stays a draft: it is not the bottom of its stack and its checks are %s", n, v.State)
+				continue
+			}
+			reason = "its checks pass"
+		}
+		promoted = append(promoted, n)
+		glog.Infof("marking PR %d ready for review because %s", n, reason)
+		if err := r.MarkReadyForReview(n); err != nil {
+			return nil, fmt.Errorf("failed to mark PR %d ready for review: %v", n, err)
+		}
+	}
+	return promoted, nil
+}
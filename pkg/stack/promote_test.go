@@ -0,0 +1,84 @@
+package stack
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/google/go-github/v28/github"
+)
+
+// ciRepo implements the parts of repo.Repo used by Promote. Commits have the
+// status in `statuses`, or none at all.
+type ciRepo struct {
+	repo.Repo
+	statuses map[string]string
+	ready    []int
+}
+
+func (f *ciRepo) CombinedStatus(ref string) (*github.CombinedStatus, error) {
+	cs := &github.CombinedStatus{}
+	if s, ok := f.statuses[ref]; ok {
+		cs.Statuses = []github.RepoStatus{{Context: github.String("build"), State: github.String(s)}}
+	}
+	return cs, nil
+}
+
+func (f *ciRepo) CheckRuns(ref string) ([]*github.CheckRun, error) {
+	return nil, nil
+}
+
+func (f *ciRepo) CheckSuites(ref string) ([]*github.CheckSuite, error) {
+	return nil, nil
+}
+
+func (f *ciRepo) MarkReadyForReview(num int) error {
+	f.ready = append(f.ready, num)
+	return nil
+}
+
+func draftPR(num int, head, base, author string) *github.PullRequest {
+	pr := newPR(num, head, base)
+	pr.Draft = github.Bool(true)
+	pr.User = &github.User{Login: github.String(author)}
+	pr.Head.SHA = github.String(head + "-sha")
+	return pr
+}
+
+func TestPromote(t *testing.T) {
+	prs := []*github.PullRequest{
+		draftPR(1, "a", "master", "me"),
+		draftPR(2, "b", "a", "me"),
+		draftPR(3, "c", "b", "me"),
+		draftPR(4, "d", "c", "me"),
+		draftPR(5, "x", "master", "other"),
+		newPR(6, "y", "master"),
+	}
+	statuses := map[string]string{"b-sha": "success", "c-sha": "pending", "y-sha": "success"}
+	tests := []struct {
+		name    string
+		author  string
+		onGreen bool
+		want    []int
+		ready   []int
+	}{
+		{name: "bottoms", author: "me", want: []int{1}, ready: []int{1}},
+		{name: "green", author: "me", onGreen: true, want: []int{1, 2}, ready: []int{1, 2}},
+		{name: "any author", want: []int{1, 5}, ready: []int{1, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &ciRepo{statuses: statuses}
+			got, err := Promote(f, prs, tt.author, tt.onGreen)
+			if err != nil {
+				t.Fatalf("Promote() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Promote() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(f.ready, tt.ready) {
+				t.Errorf("Promote() marked %v ready, want %v", f.ready, tt.ready)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// Retarget changes the base of every open pull request which is based on the
+// head of `merged` to be the base of `merged`. It returns the numbers of the
+// pull requests which were retargeted.
+func Retarget(r repo.Repo, merged *github.PullRequest) ([]int, error) {
+	if !HeadInBase(merged) {
+		glog.V(1).Infof("PR %d is from %s, so no pull requests can be based on it", merged.GetNumber(), merged.GetHead().GetLabel())
+		return nil, nil
+	}
+	ref := merged.GetHead().GetRef()
+	newBase := merged.GetBase().GetRef()
+	prs, err := r.PullRequests()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get pull requests: %v", err)
+	}
+	var retargeted []int
+	for _, pr := range prs {
+		if pr.GetBase().GetRef() != ref {
+			continue
+		}
+		retargeted = append(retargeted, pr.GetNumber())
+		glog.Infof("PR %d matched branch %s, changing base to %s", pr.GetNumber(), ref, newBase)
+		if err := r.ChangePullRequestBase(pr.GetNumber(), newBase); err != nil {
+			return nil, fmt.Errorf("failed to change base: %v", err)
+		}
+	}
+	return retargeted, nil
+}
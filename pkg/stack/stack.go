@@ -0,0 +1,106 @@
+package stack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// Find returns the linear stack of pull requests which contains pull request
+// `num`, ordered from the bottom (the PR based on a non-PR branch) to the top.
+// Pull requests are linked when the base ref of one is the head ref of
+// another whose head is in the same repository (see HeadInBase).
+func Find(prs []*github.PullRequest, num int) ([]*github.PullRequest, error) {
+	byHead := make(map[string]*github.PullRequest)
+	byBase := make(map[string][]*github.PullRequest)
+	var current *github.PullRequest
+	for _, pr := range prs {
+		if HeadInBase(pr) {
+			byHead[pr.GetHead().GetRef()] = pr
+		}
+		byBase[pr.GetBase().GetRef()] = append(byBase[pr.GetBase().GetRef()], pr)
+		if pr.GetNumber() == num {
+			current = pr
+		}
+	}
+	if current == nil {
+		return nil, fmt.Errorf("pull request %d not found", num)
+	}
+
+	seen := map[int]bool{current.GetNumber(): true}
+	var below []*github.PullRequest
+	for pr := byHead[current.GetBase().GetRef()]; pr != nil; pr = byHead[pr.GetBase().GetRef()] {
+		if seen[pr.GetNumber()] {
+			return nil, fmt.Errorf("pull request %d is part of a cycle", pr.GetNumber())
+		}
+		seen[pr.GetNumber()] = true
+		below = append(below, pr)
+	}
+
+	var stack []*github.PullRequest
+	for i := len(below) - 1; i >= 0; i-- {
+		stack = append(stack, below[i])
+	}
+	stack = append(stack, current)
+
+	for pr := current; HeadInBase(pr); {
+		children := byBase[pr.GetHead().GetRef()]
+		if len(children) == 0 {
+			break
+		}
+		if len(children) > 1 {
+			return nil, fmt.Errorf("only linear stacks are supported, but %d and %d both depend on %d", children[0].GetNumber(), children[1].GetNumber(), pr.GetNumber())
+		}
+		pr = children[0]
+		if seen[pr.GetNumber()] {
+			return nil, fmt.Errorf("pull request %d is part of a cycle", pr.GetNumber())
+		}
+		seen[pr.GetNumber()] = true
+		stack = append(stack, pr)
+	}
+	return stack, nil
+}
+
+// Bottoms returns the pull requests in `prs` which are not based on the head
+// of another pull request in `prs`, sorted by number.
+func Bottoms(prs []*github.PullRequest) []*github.PullRequest {
+	heads := make(map[string]bool)
+	for _, pr := range prs {
+		if HeadInBase(pr) {
+			heads[pr.GetHead().GetRef()] = true
+		}
+	}
+	var res []*github.PullRequest
+	for _, pr := range prs {
+		if !heads[pr.GetBase().GetRef()] {
+			res = append(res, pr)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].GetNumber() < res[j].GetNumber() })
+	return res
+}
+
+// HeadInBase reports whether the head branch of `pr` is in the repository the
+// pull request is against, rather than in a fork. Only such branches can be the
+// base of other pull requests; a branch of the same name in a fork is a
+// different branch.
+func HeadInBase(pr *github.PullRequest) bool {
+	return strings.EqualFold(pr.GetHead().GetRepo().GetFullName(), pr.GetBase().GetRepo().GetFullName())
+}
+
+// State returns a short description of the state of `pr`: one of "merged",
+// "closed", "draft" or "open".
+func State(pr *github.PullRequest) string {
+	switch {
+	case pr.GetMerged():
+		return "merged"
+	case pr.GetState() == "closed":
+		return "closed"
+	case pr.GetDraft():
+		return "draft"
+	default:
+		return "open"
+	}
+}
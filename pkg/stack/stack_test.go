@@ -0,0 +1,164 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func newPR(num int, head, base string) *github.PullRequest {
+	return &github.PullRequest{
+		Number: github.Int(num),
+		Title:  github.String(head),
+		Head:   &github.PullRequestBranch{Ref: github.String(head)},
+		Base:   &github.PullRequestBranch{Ref: github.String(base)},
+	}
+}
+
+// newForkPR returns a pull request against owner/repo whose head is in the
+// fork/repo fork.
+func newForkPR(num int, head, base string) *github.PullRequest {
+	pr := newPR(num, head, base)
+	pr.Head.Repo = &github.Repository{FullName: github.String("fork/repo")}
+	pr.Base.Repo = &github.Repository{FullName: github.String("owner/repo")}
+	return pr
+}
+
+func TestFind(t *testing.T) {
+	prs := []*github.PullRequest{
+		newPR(3, "c", "b"),
+		newPR(1, "a", "master"),
+		newPR(2, "b", "a"),
+		newPR(4, "x", "master"),
+	}
+	tests := []struct {
+		name    string
+		prs     []*github.PullRequest
+		num     int
+		want    []int
+		wantErr bool
+	}{
+		{name: "bottom", prs: prs, num: 1, want: []int{1, 2, 3}},
+		{name: "middle", prs: prs, num: 2, want: []int{1, 2, 3}},
+		{name: "top", prs: prs, num: 3, want: []int{1, 2, 3}},
+		{name: "alone", prs: prs, num: 4, want: []int{4}},
+		{name: "missing", prs: prs, num: 5, wantErr: true},
+		{
+			name:    "fork",
+			prs:     append([]*github.PullRequest{newPR(5, "d", "a")}, prs...),
+			num:     1,
+			wantErr: true,
+		},
+		{
+			name: "head in fork",
+			prs:  []*github.PullRequest{newForkPR(6, "y", "master"), newPR(7, "z", "y")},
+			num:  7,
+			want: []int{7},
+		},
+		{
+			name: "head in fork bottom",
+			prs:  []*github.PullRequest{newForkPR(6, "y", "master"), newPR(7, "z", "y")},
+			num:  6,
+			want: []int{6},
+		},
+		{
+			name:    "cycle",
+			prs:     []*github.PullRequest{newPR(1, "a", "b"), newPR(2, "b", "a")},
+			num:     1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Find(tt.prs, tt.num)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Find() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Find() returned %d pull requests, want %d", len(got), len(tt.want))
+			}
+			for i, pr := range got {
+				if pr.GetNumber() != tt.want[i] {
+					t.Errorf("Find()[%d] = %d, want %d", i, pr.GetNumber(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBottoms(t *testing.T) {
+	prs := []*github.PullRequest{
+		newPR(3, "c", "b"),
+		newPR(1, "a", "master"),
+		newPR(2, "b", "a"),
+		newPR(4, "x", "master"),
+		newForkPR(5, "y", "master"),
+		newPR(6, "z", "y"),
+	}
+	var got []int
+	for _, pr := range Bottoms(prs) {
+		got = append(got, pr.GetNumber())
+	}
+	want := []int{1, 4, 5, 6}
+	if len(got) != len(want) {
+		t.Fatalf("Bottoms() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Bottoms() = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestReplaceNavigation(t *testing.T) {
+	section := NavigationBegin + "\nnew\n" + NavigationEnd
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "empty body",
+			body: "",
+			want: section + "\n",
+		},
+		{
+			name: "no section",
+			body: "Some text.\n",
+			want: "Some text.\n\n" + section + "\n",
+		},
+		{
+			name: "existing section",
+			body: "Before.\n\n" + NavigationBegin + "\nold\n" + NavigationEnd + "\n\nAfter.\n",
+			want: "Before.\n\n" + section + "\n\nAfter.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReplaceNavigation(tt.body, section); got != tt.want {
+				t.Errorf("ReplaceNavigation() = %q, want %q", got, tt.want)
+			}
+			if got := ReplaceNavigation(tt.want, section); got != tt.want {
+				t.Errorf("ReplaceNavigation() is not idempotent: %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	s := []*github.PullRequest{newPR(1, "a", "master"), newPR(2, "b", "a")}
+	s[1].Draft = github.Bool(true)
+	got := Navigation(s, 2)
+	for _, want := range []string{"#1 a (open)", currentMarker + " #2 b (draft)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Navigation() = %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, currentMarker+" #1") {
+		t.Errorf("Navigation() = %q, marks the wrong pull request", got)
+	}
+}
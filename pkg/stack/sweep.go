@@ -0,0 +1,141 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// Retargeting records a change of base for a pull request. Upstream is the
+// last head SHA of the closed pull request which owned From; the commits
+// reachable from it are the ones which no longer belong on the pull request.
+type Retargeting struct {
+	Number   int
+	From     string
+	To       string
+	Upstream string
+}
+
+// sweeper resolves the base branch each open pull request should have.
+type sweeper struct {
+	r    repo.Repo
+	open map[string]bool
+	// heads maps the name of each branch in the repository to its head SHA.
+	heads    map[string]string
+	resolved map[string]string
+	upstream map[string]string
+}
+
+// latestClosed returns the most recently closed pull request in `prs`.
+func latestClosed(prs []*github.PullRequest) *github.PullRequest {
+	var latest *github.PullRequest
+	for _, pr := range prs {
+		if latest == nil || pr.GetClosedAt().After(latest.GetClosedAt()) {
+			latest = pr
+		}
+	}
+	return latest
+}
+
+// resolve returns the branch that pull requests based on `ref` should be based
+// on. If `ref` was the head of a pull request which was closed and its branch
+// deleted, or which was merged and its branch is still at the merged head, that
+// is the (resolved) base of that pull request; otherwise it is `ref` itself. A
+// long-lived branch which was merged once and has moved on since is kept.
+func (s *sweeper) resolve(ref string, seen map[string]bool) (string, error) {
+	if to, ok := s.resolved[ref]; ok {
+		return to, nil
+	}
+	if s.open[ref] {
+		return ref, nil
+	}
+	if seen[ref] {
+		return "", fmt.Errorf("branch %s is part of a cycle of closed pull requests", ref)
+	}
+	seen[ref] = true
+	closed, err := s.r.ClosedPullRequests(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to get closed pull requests for %s: %v", ref, err)
+	}
+	to := ref
+	if pr := latestClosed(closed); pr != nil && s.finished(ref, pr) {
+		glog.V(1).Infof("branch %s belongs to closed PR %d (merged at %v)", ref, pr.GetNumber(), pr.GetMergedAt())
+		s.upstream[ref] = pr.GetHead().GetSHA()
+		if to, err = s.resolve(pr.GetBase().GetRef(), seen); err != nil {
+			return "", err
+		}
+	}
+	s.resolved[ref] = to
+	return to, nil
+}
+
+// finished reports whether branch `ref` is done with now that closed pull
+// request `pr`, whose head it was, is closed: its branch was deleted, or `pr`
+// was merged and the branch has not moved on since.
+func (s *sweeper) finished(ref string, pr *github.PullRequest) bool {
+	head, ok := s.heads[ref]
+	if !ok {
+		return true
+	}
+	// The merged field is not filled in when listing pull requests, but
+	// merged_at is.
+	if pr.GetMergedAt().IsZero() {
+		return false
+	}
+	if head != pr.GetHead().GetSHA() {
+		glog.V(1).Infof("branch %s has moved from %s to %s since PR %d was merged, keeping it", ref, pr.GetHead().GetSHA(), head, pr.GetNumber())
+		return false
+	}
+	return true
+}
+
+// Sweep retargets every open pull request whose base branch belongs to a
+// merged pull request and has not moved since, or to a closed pull request
+// whose branch was deleted, to the base of that pull request. This is repeated
+// through chains of such pull requests, so a pull request whose parent and
+// grandparent were both merged ends up based on the grandparent's base.
+func Sweep(r repo.Repo) ([]Retargeting, error) {
+	prs, err := r.PullRequests()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get pull requests: %v", err)
+	}
+	branches, err := r.Branches()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get branches: %v", err)
+	}
+	s := &sweeper{
+		r:        r,
+		open:     make(map[string]bool),
+		heads:    make(map[string]string),
+		resolved: make(map[string]string),
+		upstream: make(map[string]string),
+	}
+	for _, pr := range prs {
+		if HeadInBase(pr) {
+			s.open[pr.GetHead().GetRef()] = true
+		}
+	}
+	for _, b := range branches {
+		s.heads[b.GetName()] = b.GetCommit().GetSHA()
+	}
+
+	var res []Retargeting
+	for _, pr := range prs {
+		from := pr.GetBase().GetRef()
+		to, err := s.resolve(from, make(map[string]bool))
+		if err != nil {
+			return res, fmt.Errorf("unable to resolve base of PR %d: %v", pr.GetNumber(), err)
+		}
+		if to == from {
+			continue
+		}
+		res = append(res, Retargeting{Number: pr.GetNumber(), From: from, To: to, Upstream: s.upstream[from]})
+		glog.Infof("PR %d is based on closed branch %s, changing base to %s", pr.GetNumber(), from, to)
+		if err := r.ChangePullRequestBase(pr.GetNumber(), to); err != nil {
+			return res, fmt.Errorf("failed to change base: %v", err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,136 @@
+package stack
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bretmckee/git-tools/pkg/repo"
+	"github.com/google/go-github/v28/github"
+)
+
+// fakeRepo serves fixed lists of open and closed pull requests and branches,
+// and records the bases changed, comments added, states set and branches
+// deleted, by pull request or branch, for the Sweep, Drop and Cleanup tests.
+type fakeRepo struct {
+	repo.Repo
+	open     []*github.PullRequest
+	closed   []*github.PullRequest
+	branches []string
+	// moved are branches whose head is no longer the head of their last
+	// closed pull request.
+	moved     []string
+	protected []string
+	changed   map[int]string
+	comments  map[int]string
+	states    map[int]string
+	deleted   []string
+}
+
+func (f *fakeRepo) PullRequests() ([]*github.PullRequest, error) {
+	return f.open, nil
+}
+
+func (f *fakeRepo) ClosedPullRequests(branch string) ([]*github.PullRequest, error) {
+	var res []*github.PullRequest
+	for _, pr := range f.closed {
+		if pr.GetHead().GetRef() == branch {
+			res = append(res, pr)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeRepo) Branches() ([]*github.Branch, error) {
+	var res []*github.Branch
+	for _, b := range f.branches {
+		res = append(res, &github.Branch{Name: github.String(b), Commit: f.head(b), Protected: github.Bool(false)})
+	}
+	for _, b := range f.protected {
+		res = append(res, &github.Branch{Name: github.String(b), Commit: f.head(b), Protected: github.Bool(true)})
+	}
+	return res, nil
+}
+
+// head returns the head commit of branch `name`, which is the head of its
+// closed pull requests unless it has moved.
+func (f *fakeRepo) head(name string) *github.RepositoryCommit {
+	sha := name + "-sha"
+	for _, m := range f.moved {
+		if m == name {
+			sha = name + "-new-sha"
+		}
+	}
+	return &github.RepositoryCommit{SHA: github.String(sha)}
+}
+
+func (f *fakeRepo) ChangePullRequestBase(num int, ref string) error {
+	f.changed[num] = ref
+	return nil
+}
+
+func (f *fakeRepo) AddComment(num int, body string) error {
+	f.comments[num] = body
+	return nil
+}
+
+func (f *fakeRepo) EditPullRequest(num int, pr *github.PullRequest) (*github.PullRequest, error) {
+	f.states[num] = pr.GetState()
+	return pr, nil
+}
+
+func (f *fakeRepo) DeleteBranch(name string) error {
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+func closedPR(num int, head, base string, merged bool) *github.PullRequest {
+	pr := newPR(num, head, base)
+	closedAt := time.Date(2020, 1, 1, 0, num, 0, 0, time.UTC)
+	pr.ClosedAt = &closedAt
+	pr.Head.SHA = github.String(head + "-sha")
+	if merged {
+		pr.MergedAt = &closedAt
+	}
+	return pr
+}
+
+func TestSweep(t *testing.T) {
+	f := &fakeRepo{
+		open: []*github.PullRequest{
+			newPR(10, "d", "c"),
+			newPR(11, "e", "d"),
+			newPR(12, "x", "abandoned"),
+			newPR(13, "y", "kept"),
+			newPR(14, "z", "master"),
+			newPR(15, "w", "develop"),
+		},
+		closed: []*github.PullRequest{
+			closedPR(1, "a", "master", true),
+			closedPR(2, "b", "a", true),
+			closedPR(3, "c", "b", true),
+			closedPR(4, "abandoned", "master", false),
+			closedPR(5, "kept", "master", false),
+			closedPR(6, "develop", "master", true),
+		},
+		branches: []string{"master", "c", "d", "develop", "e", "kept", "w", "x", "y", "z"},
+		moved:    []string{"develop"},
+		changed:  make(map[int]string),
+	}
+
+	got, err := Sweep(f)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	want := []Retargeting{
+		{Number: 10, From: "c", To: "master", Upstream: "c-sha"},
+		{Number: 12, From: "abandoned", To: "master", Upstream: "abandoned-sha"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sweep() = %+v, want %+v", got, want)
+	}
+	wantChanged := map[int]string{10: "master", 12: "master"}
+	if !reflect.DeepEqual(f.changed, wantChanged) {
+		t.Errorf("Sweep() changed %v, want %v", f.changed, wantChanged)
+	}
+}
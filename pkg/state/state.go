@@ -0,0 +1,83 @@
+// Package state records the actions taken by long-running commands, so that
+// they are not repeated after a restart.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is a set of actions which have been taken, saved to a file whenever it
+// changes.
+type State struct {
+	path string
+	// Done maps the key of each action taken to when it was taken.
+	Done map[string]time.Time `json:"done"`
+}
+
+// DefaultPath returns the path of the state file for command `name` used when
+// none is specified: $XDG_STATE_HOME/git-tools/name.json, or
+// ~/.local/state/git-tools/name.json.
+func DefaultPath(name string) string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".local", "state")
+	}
+	return filepath.Join(dir, "git-tools", name+".json")
+}
+
+// Load reads the state file at `path`. A missing file is not an error; it
+// results in an empty state.
+func Load(path string) (*State, error) {
+	s := &State{path: path, Done: make(map[string]time.Time)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state %q: %v", path, err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse state %q: %v", path, err)
+	}
+	if s.Done == nil {
+		s.Done = make(map[string]time.Time)
+	}
+	return s, nil
+}
+
+// Did reports whether the action with key `key` has been taken.
+func (s *State) Did(key string) bool {
+	_, ok := s.Done[key]
+	return ok
+}
+
+// Record records that the action with key `key` was taken and saves the state.
+func (s *State) Record(key string) error {
+	s.Done[key] = time.Now()
+	return s.save()
+}
+
+// save writes the state to a temporary file and renames it over the state
+// file, so an interrupted write never leaves a truncated file behind.
+func (s *State) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %v", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state %q: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace state %q: %v", s.path, err)
+	}
+	return nil
+}
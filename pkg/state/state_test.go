@@ -0,0 +1,31 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "state.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() of missing file error = %v", err)
+	}
+	if s.Did("a") {
+		t.Errorf("Did(a) = true for an empty state")
+	}
+	if err := s.Record("a"); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	s, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !s.Did("a") {
+		t.Errorf("Did(a) = false after reloading")
+	}
+	if s.Did("b") {
+		t.Errorf("Did(b) = true, but it was never recorded")
+	}
+}
@@ -0,0 +1,242 @@
+// Package trailers parses the trailers at the end of commit messages, using
+// the same rules as `git interpret-trailers`.
+//
+// The trailer block is the last paragraph of the message, provided that it is
+// not the first paragraph (the subject). As a convention for these tools, a
+// line consisting of just Divider also starts a paragraph, so a message can
+// end with:
+//
+//	Some description of the change.
+//	__
+//	bretmckee-branch: some-branch
+//
+// A paragraph is a trailer block if every line in it is a trailer or a
+// continuation line (one starting with whitespace), or if at least a quarter of
+// its lines are trailers and one of them was generated by git (for example
+// Signed-off-by).
+package trailers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultSeparators are the separators git recognizes between a trailer
+	// key and its value when trailer.separators is not configured.
+	DefaultSeparators = ":"
+
+	// Divider is a line which separates the trailers from the rest of the
+	// message.
+	Divider = "__"
+)
+
+const (
+	// signedOff is the prefix of the trailer added by `git commit -s`.
+	signedOff = "Signed-off-by: "
+	// cherryPicked is the prefix of the line added by `git cherry-pick -x`.
+	// Git counts it as a trailer even though it has no separator.
+	cherryPicked = "(cherry picked from commit "
+)
+
+// branchRE matches the values which are accepted for a branch directive.
+var branchRE = regexp.MustCompile(`^[/A-Za-z0-9_.-]+$`)
+
+// Trailer is a single key/value pair. Folded values are unfolded into a single
+// line.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// Trailers is an ordered list of trailers.
+type Trailers []Trailer
+
+// Message is a commit message split into the part before its trailer block
+// and the trailers themselves.
+type Message struct {
+	// Body is the message without its trailer block, divider, or trailing
+	// blank lines.
+	Body     string
+	Trailers Trailers
+}
+
+// Parse parses `msg` using DefaultSeparators.
+func Parse(msg string) *Message {
+	return ParseSeparators(msg, DefaultSeparators)
+}
+
+// ParseSeparators parses `msg`, accepting any of the characters in
+// `separators` between a trailer key and its value.
+func ParseSeparators(msg, separators string) *Message {
+	lines := strings.Split(strings.TrimRight(msg, "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	// The trailer block starts after the last blank or divider line, which
+	// must come after the subject paragraph.
+	start := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if isBreak(lines[i]) {
+			start = i + 1
+			break
+		}
+	}
+	if start <= 0 || !hasSubject(lines[:start]) {
+		return &Message{Body: strings.Join(lines, "\n")}
+	}
+
+	trailers, ok := parseBlock(lines[start:], separators)
+	if !ok {
+		return &Message{Body: strings.Join(lines, "\n")}
+	}
+
+	body := lines[:start]
+	for len(body) > 0 && isBreak(body[len(body)-1]) {
+		body = body[:len(body)-1]
+	}
+	return &Message{Body: strings.Join(body, "\n"), Trailers: trailers}
+}
+
+func isBreak(line string) bool {
+	l := strings.TrimSpace(line)
+	return l == "" || l == Divider
+}
+
+// hasSubject reports whether `lines` contains a paragraph before the break
+// which ends it.
+func hasSubject(lines []string) bool {
+	for _, l := range lines {
+		if !isBreak(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBlock parses the lines of a candidate trailer block. It reports false if
+// the lines do not form a trailer block.
+func parseBlock(lines []string, separators string) (Trailers, bool) {
+	var trailers Trailers
+	trailerLines, nonTrailers := 0, 0
+	generated := false
+	last := -1
+	for _, line := range lines {
+		if line[0] == ' ' || line[0] == '\t' {
+			if last >= 0 {
+				v := strings.TrimSpace(line)
+				if trailers[last].Value == "" {
+					trailers[last].Value = v
+				} else if v != "" {
+					trailers[last].Value += " " + v
+				}
+			}
+			continue
+		}
+		last = -1
+		if strings.HasPrefix(line, cherryPicked) {
+			trailerLines++
+			generated = true
+			continue
+		}
+		key, value, ok := split(line, separators)
+		if !ok {
+			nonTrailers++
+			continue
+		}
+		if strings.HasPrefix(line, signedOff) {
+			generated = true
+		}
+		trailers = append(trailers, Trailer{Key: key, Value: value})
+		trailerLines++
+		last = len(trailers) - 1
+	}
+	if trailerLines == 0 {
+		return nil, false
+	}
+	if nonTrailers == 0 || generated && trailerLines*3 >= nonTrailers {
+		return trailers, true
+	}
+	return nil, false
+}
+
+// split splits a trailer line into its key and value. The key must consist of
+// letters, digits and dashes, optionally followed by whitespace before the
+// separator.
+func split(line, separators string) (string, string, bool) {
+	i := strings.IndexAny(line, separators)
+	if i <= 0 {
+		return "", "", false
+	}
+	key := strings.TrimRight(line[:i], " \t")
+	if key == "" {
+		return "", "", false
+	}
+	for _, r := range key {
+		if !(r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return "", "", false
+		}
+	}
+	return key, strings.TrimSpace(line[i+1:]), true
+}
+
+// Get returns the values of every trailer whose key matches `key`, ignoring
+// case.
+func (t Trailers) Get(key string) []string {
+	var values []string
+	for _, tr := range t {
+		if strings.EqualFold(tr.Key, key) {
+			values = append(values, tr.Value)
+		}
+	}
+	return values
+}
+
+// Without returns the trailers whose keys do not match any of `keys`, ignoring
+// case.
+func (t Trailers) Without(keys ...string) Trailers {
+	var res Trailers
+	for _, tr := range t {
+		match := false
+		for _, k := range keys {
+			if strings.EqualFold(tr.Key, k) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			res = append(res, tr)
+		}
+	}
+	return res
+}
+
+// String formats the trailers one per line, as `Key: Value`.
+func (t Trailers) String() string {
+	var b strings.Builder
+	for _, tr := range t {
+		fmt.Fprintf(&b, "%s: %s\n", tr.Key, tr.Value)
+	}
+	return b.String()
+}
+
+// Branch returns the value of the branch directive `directive`, or "" if the
+// message does not have one. It is an error for the directive to appear more
+// than once or to have a value which is not a valid branch name.
+func (m *Message) Branch(directive string) (string, error) {
+	values := m.Trailers.Get(directive)
+	switch len(values) {
+	case 0:
+		return "", nil
+	case 1:
+	default:
+		return "", fmt.Errorf("multiple %q directives", directive)
+	}
+	v := values[0]
+	if !branchRE.MatchString(v) || strings.HasPrefix(v, "/") || strings.HasSuffix(v, "/") || strings.Contains(v, "..") || strings.HasSuffix(v, ".lock") {
+		return "", fmt.Errorf("invalid %q directive %q", directive, v)
+	}
+	return v, nil
+}
@@ -0,0 +1,187 @@
+package trailers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		msg          string
+		wantBody     string
+		wantTrailers Trailers
+	}{
+		{
+			name:     "subject only",
+			msg:      "Subject: with a colon\n",
+			wantBody: "Subject: with a colon",
+		},
+		{
+			name:     "no trailers",
+			msg:      "Subject\n\nSome body text.\nMore text.\n",
+			wantBody: "Subject\n\nSome body text.\nMore text.",
+		},
+		{
+			name:         "blank line",
+			msg:          "Subject\n\nBody.\n\nbretmckee-branch: update-readme\n",
+			wantBody:     "Subject\n\nBody.",
+			wantTrailers: Trailers{{"bretmckee-branch", "update-readme"}},
+		},
+		{
+			name:         "divider",
+			msg:          "Update README.md\n\nAdd some more information to the read me.\n__\nbretmckee-branch: update-readme\n",
+			wantBody:     "Update README.md\n\nAdd some more information to the read me.",
+			wantTrailers: Trailers{{"bretmckee-branch", "update-readme"}},
+		},
+		{
+			name:         "trailing blank lines",
+			msg:          "Subject\n\nKey: value\n\n\n",
+			wantBody:     "Subject",
+			wantTrailers: Trailers{{"Key", "value"}},
+		},
+		{
+			name:         "folded value",
+			msg:          "Subject\n\nReviewers: alice,\n  bob,\n\tcarol\nLabels: db\n",
+			wantBody:     "Subject",
+			wantTrailers: Trailers{{"Reviewers", "alice, bob, carol"}, {"Labels", "db"}},
+		},
+		{
+			name:         "whitespace before separator",
+			msg:          "Subject\n\nKey :value\n",
+			wantBody:     "Subject",
+			wantTrailers: Trailers{{"Key", "value"}},
+		},
+		{
+			name:         "empty value",
+			msg:          "Subject\n\nKey:\n",
+			wantBody:     "Subject",
+			wantTrailers: Trailers{{"Key", ""}},
+		},
+		{
+			name:     "invalid key",
+			msg:      "Subject\n\nNot a key: value\n",
+			wantBody: "Subject\n\nNot a key: value",
+		},
+		{
+			name:     "mixed paragraph",
+			msg:      "Subject\n\nSome text.\nKey: value\n",
+			wantBody: "Subject\n\nSome text.\nKey: value",
+		},
+		{
+			name:         "mixed paragraph with git trailer",
+			msg:          "Subject\n\nSome text.\nSigned-off-by: A U Thor <a@example.com>\n",
+			wantBody:     "Subject",
+			wantTrailers: Trailers{{"Signed-off-by", "A U Thor <a@example.com>"}},
+		},
+		{
+			name:         "cherry picked",
+			msg:          "Subject\n\n(cherry picked from commit 0123abcd)\nKey: value\n",
+			wantBody:     "Subject",
+			wantTrailers: Trailers{{"Key", "value"}},
+		},
+		{
+			name:     "divider without subject",
+			msg:      "__\nKey: value\n",
+			wantBody: "__\nKey: value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.msg)
+			if got.Body != tt.wantBody {
+				t.Errorf("Parse() Body = %q, want %q", got.Body, tt.wantBody)
+			}
+			if !reflect.DeepEqual(got.Trailers, tt.wantTrailers) {
+				t.Errorf("Parse() Trailers = %q, want %q", got.Trailers, tt.wantTrailers)
+			}
+		})
+	}
+}
+
+func TestParseSeparators(t *testing.T) {
+	got := ParseSeparators("Subject\n\nFixes #123\nKey: value\n", ":#")
+	want := Trailers{{"Fixes", "123"}, {"Key", "value"}}
+	if !reflect.DeepEqual(got.Trailers, want) {
+		t.Errorf("ParseSeparators() Trailers = %q, want %q", got.Trailers, want)
+	}
+}
+
+func TestBranch(t *testing.T) {
+	const directive = "bretmckee-branch"
+	tests := []struct {
+		name    string
+		msg     string
+		want    string
+		wantErr bool
+	}{
+		{name: "missing", msg: "Subject\n\nKey: value\n"},
+		{name: "valid", msg: "Subject\n\nbretmckee-branch: feature/a_b.c-1\n", want: "feature/a_b.c-1"},
+		{name: "case insensitive", msg: "Subject\n\nBretmckee-Branch: a\n", want: "a"},
+		{name: "multiple", msg: "Subject\n\nbretmckee-branch: a\nbretmckee-branch: b\n", wantErr: true},
+		{name: "space", msg: "Subject\n\nbretmckee-branch: a b\n", wantErr: true},
+		{name: "double dot", msg: "Subject\n\nbretmckee-branch: a..b\n", wantErr: true},
+		{name: "leading slash", msg: "Subject\n\nbretmckee-branch: /a\n", wantErr: true},
+		{name: "lock", msg: "Subject\n\nbretmckee-branch: a.lock\n", wantErr: true},
+		{name: "empty", msg: "Subject\n\nbretmckee-branch:\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.msg).Branch(directive)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Branch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Branch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithout(t *testing.T) {
+	tr := Trailers{{"Reviewers", "alice"}, {"Labels", "db"}, {"Signed-off-by", "me"}}
+	got := tr.Without("labels", "REVIEWERS")
+	want := Trailers{{"Signed-off-by", "me"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Without() = %q, want %q", got, want)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"Subject\n",
+		"Subject\n\nBody.\n__\nbretmckee-branch: update-readme\n",
+		"Subject\n\nKey: a,\n b\n",
+		"Subject\n\ntext\nSigned-off-by: x\n",
+		"Subject\n\n(cherry picked from commit abc)\n",
+		"\n\n__\n \t\n",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, msg string) {
+		m := Parse(msg)
+		if !strings.HasPrefix(msg, m.Body) {
+			t.Errorf("Parse(%q) Body = %q, which is not a prefix of the message", msg, m.Body)
+		}
+		for _, tr := range m.Trailers {
+			if _, _, ok := split(tr.Key+": x", DefaultSeparators); !ok {
+				t.Errorf("Parse(%q) returned invalid key %q", msg, tr.Key)
+			}
+			if strings.Contains(tr.Value, "\n") {
+				t.Errorf("Parse(%q) returned value %q containing a newline", msg, tr.Value)
+			}
+		}
+		if len(m.Trailers) > 0 {
+			again := Parse(m.Body + "\n\n" + m.Trailers.String())
+			if again.Body != m.Body || !reflect.DeepEqual(again.Trailers, m.Trailers) {
+				t.Errorf("Parse(%q) does not round trip: got %+v, want %+v", msg, again, m)
+			}
+		}
+		// Errors are expected for arbitrary input, but Branch must not panic.
+		_, _ = m.Branch("bretmckee-branch")
+	})
+}
@@ -0,0 +1,71 @@
+// Package webhook receives GitHub webhook deliveries.
+package webhook
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/google/go-github/v28/github"
+)
+
+// SignatureHeader is the header holding the HMAC-SHA256 signature of the
+// payload.
+const SignatureHeader = "X-Hub-Signature-256"
+
+// maxPayload is the largest payload GitHub delivers.
+const maxPayload = 25 << 20
+
+// Verify returns an error unless `signature` (the value of SignatureHeader) is
+// the HMAC-SHA256 of `payload` with `secret`.
+func Verify(signature string, payload, secret []byte) error {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return fmt.Errorf("missing or unsupported signature %q", signature)
+	}
+	return github.ValidateSignature(signature, payload, secret)
+}
+
+// Handler is an http.Handler which verifies deliveries and passes pull request
+// events to PullRequest. Other events are acknowledged and ignored.
+type Handler struct {
+	Secret []byte
+	// PullRequest handles a pull_request event. `delivery` is the unique ID
+	// of the delivery, which is kept when GitHub redelivers it.
+	PullRequest func(delivery string, ev *github.PullRequestEvent) error
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPayload))
+	if err != nil {
+		http.Error(w, "failed to read payload", http.StatusBadRequest)
+		return
+	}
+	if err := Verify(r.Header.Get(SignatureHeader), payload, h.Secret); err != nil {
+		glog.Warningf("rejecting delivery %s: %v", github.DeliveryID(r), err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	delivery, kind := github.DeliveryID(r), github.WebHookType(r)
+	glog.V(1).Infof("received %s delivery %s", kind, delivery)
+	if kind != "pull_request" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	ev, err := github.ParseWebHook(kind, payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := h.PullRequest(delivery, ev.(*github.PullRequestEvent)); err != nil {
+		glog.Errorf("delivery %s failed: %v", delivery, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func sign(payload, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHandler(t *testing.T) {
+	const (
+		secret  = "s3cret"
+		payload = `{"action":"closed","pull_request":{"number":7,"merged":true}}`
+	)
+	tests := []struct {
+		name      string
+		method    string
+		event     string
+		signature string
+		fail      bool
+		want      int
+		wantCall  bool
+	}{
+		{name: "pull request", event: "pull_request", signature: sign(payload, secret), want: http.StatusNoContent, wantCall: true},
+		{name: "other event", event: "push", signature: sign(payload, secret), want: http.StatusNoContent},
+		{name: "wrong secret", event: "pull_request", signature: sign(payload, "other"), want: http.StatusUnauthorized},
+		{name: "sha1 signature", event: "pull_request", signature: "sha1=0123", want: http.StatusUnauthorized},
+		{name: "unsigned", event: "pull_request", want: http.StatusUnauthorized},
+		{name: "get", method: http.MethodGet, event: "pull_request", signature: sign(payload, secret), want: http.StatusMethodNotAllowed},
+		{name: "handler error", event: "pull_request", signature: sign(payload, secret), fail: true, want: http.StatusInternalServerError, wantCall: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			h := &Handler{
+				Secret: []byte(secret),
+				PullRequest: func(delivery string, ev *github.PullRequestEvent) error {
+					called = true
+					if delivery != "d1" || ev.GetAction() != "closed" || ev.GetPullRequest().GetNumber() != 7 {
+						t.Errorf("PullRequest(%q, %v %d)", delivery, ev.GetAction(), ev.GetPullRequest().GetNumber())
+					}
+					if tt.fail {
+						return errors.New("failed")
+					}
+					return nil
+				},
+			}
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/", strings.NewReader(payload))
+			req.Header.Set("X-GitHub-Event", tt.event)
+			req.Header.Set("X-GitHub-Delivery", "d1")
+			if tt.signature != "" {
+				req.Header.Set(SignatureHeader, tt.signature)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if called != tt.wantCall {
+				t.Errorf("PullRequest called = %v, want %v", called, tt.wantCall)
+			}
+		})
+	}
+}